@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/codegangsta/cli"
+)
+
+var CALL cli.Command = cli.Command{
+	Name:      "call",
+	Usage:     "call any ECS API action and print the JSON response",
+	ArgsUsage: "ACTION [Key=Value...]",
+	Description: `Parameters in arguments override those in --params file. Values of arrays
+   or objects in the file are sent as JSON strings. Example:
+
+       ecs call DescribeZones RegionId=cn-hangzhou`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "params, p",
+			Usage: "read parameters from JSON object in file, - to read from stdin",
+		},
+		cli.BoolFlag{
+			Name:  "all-regions, A",
+			Usage: "call in every region with RegionId set, print responses by region",
+		},
+		cli.StringFlag{
+			Name:  "api-version",
+			Value: "2014-05-26",
+			Usage: "API version",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if !c.Args().Present() {
+			exit("Please specify the action.")
+		}
+		params, err := callParams(c.String("params"), c.Args().Tail())
+		if err != nil {
+			exit(err)
+		}
+		params["Action"] = c.Args().First()
+		params["Version"] = c.String("api-version")
+
+		var response interface{}
+		if c.Bool("all-regions") {
+			responses := map[string]interface{}{}
+			var mutex sync.Mutex
+			err = ForAllRegionsDo(func(region string) error {
+				regionParams := map[string]string{"RegionId": region}
+				for key, value := range params {
+					regionParams[key] = value
+				}
+				var response interface{}
+				if err := ECS_INSTANCE.Request(regionParams, &response); err != nil {
+					return err
+				}
+				mutex.Lock()
+				responses[region] = response
+				mutex.Unlock()
+				return nil
+			})
+			response = responses
+		} else {
+			err = ECS_INSTANCE.Request(params, &response)
+		}
+		if response != nil {
+			pretty, jsonerr := json.MarshalIndent(response, "", "  ")
+			if jsonerr != nil {
+				exit(jsonerr)
+			}
+			fmt.Printf("%s\n", pretty)
+		}
+		if err != nil {
+			exit(err)
+		}
+	},
+}
+
+// Read parameters from JSON file and Key=Value arguments.
+func callParams(file string, args []string) (params map[string]string, err error) {
+	params = map[string]string{}
+	if file != "" {
+		var content []byte
+		if file == "-" {
+			content, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			content, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return
+		}
+		// numbers are kept as they are instead of float64 like 1e+07
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err = decoder.Decode(&object); err != nil {
+			return
+		}
+		for key, value := range object {
+			switch value.(type) {
+			case string:
+				params[key] = value.(string)
+			case json.Number:
+				params[key] = value.(json.Number).String()
+			case []interface{}, map[string]interface{}:
+				encoded, _ := json.Marshal(value)
+				params[key] = string(encoded)
+			default:
+				params[key] = fmt.Sprint(value)
+			}
+		}
+	}
+	for _, arg := range args {
+		index := strings.Index(arg, "=")
+		if index < 1 {
+			err = fmt.Errorf("Invalid parameter %s, should be Key=Value.", arg)
+			return
+		}
+		params[arg[:index]] = arg[index+1:]
+	}
+	return
+}
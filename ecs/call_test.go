@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCallParams(t *testing.T) {
+	file, err := ioutil.TempFile("", "call-params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"RegionId":"cn-hangzhou","PageSize":10,"Size":10000000,"OwnerId":1234567890123456789,"DryRun":true,"InstanceIds":["i-1","i-2"]}`)
+	file.Close()
+
+	params, err := callParams(file.Name(), []string{"RegionId=cn-qingdao", "Description=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"RegionId":    "cn-qingdao",
+		"PageSize":    "10",
+		"Size":        "10000000",
+		"OwnerId":     "1234567890123456789",
+		"DryRun":      "true",
+		"InstanceIds": `["i-1","i-2"]`,
+		"Description": "a=b",
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("callParams should be %v instead of %v", expected, params)
+	}
+	if _, err := callParams("", []string{"=value"}); err == nil {
+		t.Error("callParams should not accept parameter without key")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// braille dot bits of a cell indexed by [y][x], y from top to bottom
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+var chartColors = []string{"\033[32m", "\033[33m", "\033[36m", "\033[35m", "\033[31m", "\033[34m"}
+
+const colorReset = "\033[0m"
+
+// Average values of each bucket so that there are at most n values.
+func resample(values []float64, n int) []float64 {
+	if n < 1 || len(values) <= n {
+		return values
+	}
+	ret := make([]float64, n)
+	for i := range ret {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		sum := 0.0
+		for _, value := range values[from:to] {
+			sum += value
+		}
+		ret[i] = sum / float64(to-from)
+	}
+	return ret
+}
+
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func summarize(values []float64) (min, avg, max, p95 float64) {
+	if len(values) == 0 {
+		return
+	}
+	min, max = values[0], values[0]
+	sum := 0.0
+	for _, value := range values {
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+		sum += value
+	}
+	avg = sum / float64(len(values))
+	p95 = percentile(values, 95)
+	return
+}
+
+// Scale value between min and max to an integer between 0 and steps - 1.
+func scale(value, min, max float64, steps int) int {
+	if max <= min {
+		return 0
+	}
+	n := int(math.Floor((value - min) / (max - min) * float64(steps)))
+	if n >= steps {
+		n = steps - 1
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n
+}
+
+func sparkline(values []float64, width int, min, max float64) string {
+	values = resample(values, width)
+	runes := make([]rune, len(values))
+	for i, value := range values {
+		runes[i] = sparkTicks[scale(value, min, max, len(sparkTicks))]
+	}
+	return string(runes)
+}
+
+// Draw lines of series in a chart of width and height in characters, each
+// character has 2x4 braille dots. Color of a character is the color of the
+// last series drawn on it.
+func brailleChart(series [][]float64, width, height int, min, max float64, color bool) []string {
+	cells := make([][]rune, height)
+	colors := make([][]int, height)
+	for y := range cells {
+		cells[y] = make([]rune, width)
+		colors[y] = make([]int, width)
+	}
+	set := func(x, y, s int) {
+		cells[y/4][x/2] |= brailleDots[y%4][x%2]
+		colors[y/4][x/2] = s
+	}
+	for s, values := range series {
+		values = resample(values, width*2)
+		lastX, lastY := -1, -1
+		for i, value := range values {
+			x := 0
+			if len(values) > 1 {
+				x = i * (width*2 - 1) / (len(values) - 1)
+			}
+			y := height*4 - 1 - scale(value, min, max, height*4)
+			if lastX < 0 {
+				set(x, y, s)
+			} else {
+				drawLine(lastX, lastY, x, y, func(x, y int) { set(x, y, s) })
+			}
+			lastX, lastY = x, y
+		}
+	}
+	lines := make([]string, height)
+	for y := range cells {
+		var line []string
+		for x, cell := range cells[y] {
+			char := string(0x2800 + cell)
+			if color && cell != 0 {
+				char = chartColors[colors[y][x]%len(chartColors)] + char + colorReset
+			}
+			line = append(line, char)
+		}
+		lines[y] = strings.Join(line, "")
+	}
+	return lines
+}
+
+// Bresenham's line algorithm
+func drawLine(x0, y0, x1, y1 int, plot func(x, y int)) {
+	dx, dy := x1-x0, y1-y0
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx - dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	values := []float64{}
+	for i := 1; i <= 100; i++ {
+		values = append(values, float64(i))
+	}
+	min, avg, max, p95 := summarize(values)
+	if min != 1 || avg != 50.5 || max != 100 || p95 != 95 {
+		t.Errorf("summary of 1..100 should be 1, 50.5, 100, 95 instead of %g, %g, %g, %g", min, avg, max, p95)
+	}
+	min, avg, max, p95 = summarize([]float64{})
+	if min != 0 || avg != 0 || max != 0 || p95 != 0 {
+		t.Error("summary of nothing should be zeros")
+	}
+}
+
+func TestResample(t *testing.T) {
+	values := resample([]float64{1, 3, 5, 7}, 2)
+	if len(values) != 2 || values[0] != 2 || values[1] != 6 {
+		t.Errorf("%v should be [2 6]", values)
+	}
+	values = resample([]float64{1, 3}, 4)
+	if len(values) != 2 {
+		t.Errorf("%v should not be resampled", values)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if line := sparkline([]float64{0, 50, 100}, 10, 0, 100); line != "▁▅█" {
+		t.Errorf("%s should be ▁▅█", line)
+	}
+	if line := sparkline([]float64{1, 1}, 10, 0, 0); line != "▁▁" {
+		t.Errorf("%s should be ▁▁", line)
+	}
+}
+
+func TestBrailleChart(t *testing.T) {
+	lines := brailleChart([][]float64{{0, 100}}, 1, 1, 0, 100, false)
+	if len(lines) != 1 || lines[0] != "⡜" {
+		t.Errorf("%v should be [⡜]", lines)
+	}
+}
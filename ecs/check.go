@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// exit codes of Nagios plugins
+const (
+	CHECK_OK = iota
+	CHECK_WARNING
+	CHECK_CRITICAL
+	CHECK_UNKNOWN
+)
+
+var checkStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// Units of thresholds, case-sensitive since B is byte and b is bit.
+var thresholdUnits = map[string]thresholdUnit{
+	"":     {1, ""},
+	"%":    {1, "%"},
+	"B/s":  {1, "B/s"},
+	"KB/s": {1 << 10, "B/s"},
+	"kB/s": {1 << 10, "B/s"},
+	"MB/s": {1 << 20, "B/s"},
+	"GB/s": {1 << 30, "B/s"},
+	"bps":  {1 / 8.0, "B/s"},
+	"b/s":  {1 / 8.0, "B/s"},
+	"Kbps": {(1 << 10) / 8.0, "B/s"},
+	"kbps": {(1 << 10) / 8.0, "B/s"},
+	"Kb/s": {(1 << 10) / 8.0, "B/s"},
+	"kb/s": {(1 << 10) / 8.0, "B/s"},
+	"Mbps": {(1 << 20) / 8.0, "B/s"},
+	"Mb/s": {(1 << 20) / 8.0, "B/s"},
+	"Gbps": {(1 << 30) / 8.0, "B/s"},
+	"Gb/s": {(1 << 30) / 8.0, "B/s"},
+	"IOPS": {1, "IOPS"},
+	"iops": {1, "IOPS"},
+}
+
+type thresholdUnit struct {
+	multiplier float64
+	// unit of metrics the threshold can be used with, any if empty
+	metricUnit string
+}
+
+var thresholdRegexp = regexp.MustCompile(`^\s*([0-9.]+)\s*([A-Za-z%/]*)\s*$`)
+
+var CHECK cli.Command = cli.Command{
+	Name:      "check",
+	Usage:     "check latest stats of instances against thresholds like a Nagios plugin",
+	ArgsUsage: "[instance IDs...]",
+	Description: `Thresholds of rates can have units like 50Mbps, 10MB/s or 512KB/s, where B is byte and b is bit.
+   If no instance IDs or --regex, all running instances not hidden are checked.
+   Exit code: 0 - OK, 1 - WARNING, 2 - CRITICAL, 3 - UNKNOWN`,
+	Flags: append([]cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "regex",
+			Usage: "check instances with the name matching regex",
+		},
+		cli.IntFlag{
+			Name:  "minutes, n",
+			Value: 1,
+			Usage: "use average of datapoints of last N minutes",
+		},
+	}, thresholdFlags()...),
+	Action: func(c *cli.Context) {
+		state, message, perfdata := checkInstances(c)
+		fmt.Printf("ECS %s - %s", checkStates[state], message)
+		if len(perfdata) > 0 {
+			fmt.Printf(" | %s", strings.Join(perfdata, " "))
+		}
+		fmt.Println()
+		os.Exit(state)
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "check")
+		describeInstancesForBashComplete(nil)(c)
+	},
+}
+
+func thresholdFlags() (flags []cli.Flag) {
+	for _, metric := range INSTANCE_METRICS {
+		flags = append(flags, cli.StringFlag{
+			Name:  metric.Name + "-warn",
+			Usage: fmt.Sprintf("warning threshold of %s", metric.Title),
+		}, cli.StringFlag{
+			Name:  metric.Name + "-crit",
+			Usage: fmt.Sprintf("critical threshold of %s", metric.Title),
+		})
+	}
+	return
+}
+
+// Parse threshold like 90, 90% or 50Mbps to value in metricUnit, like B/s.
+func parseThreshold(input, metricUnit string) (float64, error) {
+	match := thresholdRegexp.FindStringSubmatch(input)
+	if match == nil {
+		return 0, fmt.Errorf("Invalid threshold %s.", input)
+	}
+	unit, ok := thresholdUnits[match[2]]
+	if !ok {
+		return 0, fmt.Errorf("Invalid unit of threshold %s.", input)
+	}
+	if unit.metricUnit != "" && unit.metricUnit != metricUnit {
+		return 0, fmt.Errorf("Unit of threshold %s should be %s.", input, metricUnit)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid threshold %s.", input)
+	}
+	return value * unit.multiplier, nil
+}
+
+type checkThreshold struct {
+	metric     instanceMetric
+	warn, crit *float64
+}
+
+func (threshold checkThreshold) state(value float64) int {
+	if threshold.crit != nil && value >= *threshold.crit {
+		return CHECK_CRITICAL
+	}
+	if threshold.warn != nil && value >= *threshold.warn {
+		return CHECK_WARNING
+	}
+	return CHECK_OK
+}
+
+func (threshold checkThreshold) perfdata(label string, value float64) string {
+	uom := ""
+	if threshold.metric.Unit == "%" {
+		uom = "%"
+	}
+	str := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64)
+	}
+	return fmt.Sprintf("'%s'=%s%s;%s;%s", strings.Replace(label, "'", "''", -1),
+		strconv.FormatFloat(value, 'f', 2, 64), uom, str(threshold.warn), str(threshold.crit))
+}
+
+func average(data ECSInstanceMonitorData, metric instanceMetric, period int) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, datum := range data {
+		sum += metric.Value(datum, period)
+	}
+	return sum / float64(len(data))
+}
+
+func checkInstances(c *cli.Context) (state int, message string, perfdata []string) {
+	var thresholds []checkThreshold
+	for _, metric := range INSTANCE_METRICS {
+		threshold := checkThreshold{metric: metric}
+		for _, level := range []string{"warn", "crit"} {
+			input := c.String(metric.Name + "-" + level)
+			if input == "" {
+				continue
+			}
+			value, err := parseThreshold(input, metric.Unit)
+			if err != nil {
+				return CHECK_UNKNOWN, err.Error(), nil
+			}
+			if level == "warn" {
+				threshold.warn = &value
+			} else {
+				threshold.crit = &value
+			}
+		}
+		if threshold.warn != nil || threshold.crit != nil {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	if len(thresholds) == 0 {
+		return CHECK_UNKNOWN, "no thresholds specified", nil
+	}
+
+	for _, regex := range c.StringSlice("regex") {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return CHECK_UNKNOWN, err.Error(), nil
+		}
+		matchRegexes = append(matchRegexes, re)
+	}
+	var instances ECSInstances
+	if c.Args().Present() {
+		for _, arg := range c.Args() {
+			instance, err := ECS_INSTANCE.DescribeInstanceAttributeById(getFirstPart(arg))
+			if err != nil {
+				return CHECK_UNKNOWN, err.Error(), nil
+			}
+			instances = append(instances, instance)
+		}
+	} else {
+		all, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			return CHECK_UNKNOWN, err.Error(), nil
+		}
+		for _, instance := range all {
+			if instance.Status == "Running" && shouldShow(instance) {
+				instances = append(instances, instance)
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return CHECK_UNKNOWN, "no instances to check", nil
+	}
+
+	minutes := c.Int("minutes")
+	if minutes < 1 {
+		minutes = 1
+	}
+	end := time.Now().UTC()
+	start := end.Add(-time.Duration(minutes+5) * time.Minute)
+	var problems []string
+	for _, instance := range instances {
+		data, _, err := ECS_INSTANCE.DescribeInstanceMonitorData(instance.InstanceId, start, end, 60)
+		if err != nil {
+			return CHECK_UNKNOWN, fmt.Sprintf("%s: %s", instance.InstanceName, err), nil
+		}
+		if len(data) == 0 {
+			return CHECK_UNKNOWN, fmt.Sprintf("%s: no monitor data", instance.InstanceName), nil
+		}
+		if len(data) > minutes {
+			data = data[len(data)-minutes:]
+		}
+		for _, threshold := range thresholds {
+			value := average(data, threshold.metric, 60)
+			label := instance.InstanceName + " " + threshold.metric.Name
+			perfdata = append(perfdata, threshold.perfdata(label, value))
+			s := threshold.state(value)
+			if s == CHECK_OK {
+				continue
+			}
+			if s > state {
+				state = s
+			}
+			problems = append(problems, fmt.Sprintf("%s is %s (%s)", label, humanValue(value, threshold.metric.Unit), checkStates[s]))
+		}
+	}
+	if len(problems) > 0 {
+		message = strings.Join(problems, ", ")
+	} else {
+		message = fmt.Sprintf("%d instance(s) OK", len(instances))
+	}
+	return
+}
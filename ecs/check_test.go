@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func testParseThreshold(t *testing.T, input, unit string, expected float64) {
+	actual, err := parseThreshold(input, unit)
+	if err != nil {
+		t.Errorf("%s should be parsed: %s", input, err)
+	} else if actual != expected {
+		t.Errorf("%s should be %g instead of %g", input, expected, actual)
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	testParseThreshold(t, "90", "%", 90)
+	testParseThreshold(t, "90%", "%", 90)
+	testParseThreshold(t, "512KB/s", "B/s", 512*1024)
+	testParseThreshold(t, "50Mbps", "B/s", 50*1024*1024/8)
+	testParseThreshold(t, "1.5 MB/s", "B/s", 1.5*1024*1024)
+	testParseThreshold(t, "8Mb/s", "B/s", 1024*1024)
+	testParseThreshold(t, "100 IOPS", "IOPS", 100)
+	testParseThreshold(t, "1000", "B/s", 1000)
+	for _, input := range []string{"", "fast", "10 parsecs", "10mb/s", "10MBPS"} {
+		if _, err := parseThreshold(input, "B/s"); err == nil {
+			t.Errorf("%s should not be parsed", input)
+		}
+	}
+	for input, unit := range map[string]string{"50Mbps": "%", "90%": "B/s", "100 IOPS": "B/s", "10MB/s": "IOPS"} {
+		if _, err := parseThreshold(input, unit); err == nil {
+			t.Errorf("%s should not be parsed for metric in %s", input, unit)
+		}
+	}
+}
+
+func TestCheckThreshold(t *testing.T) {
+	warn, crit := 70.0, 90.0
+	threshold := checkThreshold{metric: INSTANCE_METRICS[0], warn: &warn, crit: &crit}
+	for value, expected := range map[float64]int{10: CHECK_OK, 70: CHECK_WARNING, 89.9: CHECK_WARNING, 95: CHECK_CRITICAL} {
+		if actual := threshold.state(value); actual != expected {
+			t.Errorf("state of %g should be %s instead of %s", value, checkStates[expected], checkStates[actual])
+		}
+	}
+	if perfdata := threshold.perfdata("web-01 cpu", 13); perfdata != "'web-01 cpu'=13.00%;70;90" {
+		t.Errorf("%s is wrong perfdata", perfdata)
+	}
+}
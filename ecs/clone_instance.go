@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var CLONE_INSTANCE cli.Command = cli.Command{
+	Name:      "clone-instance",
+	Aliases:   []string{"clone"},
+	Usage:     "create an instance like an existing one",
+	ArgsUsage: "SOURCE",
+	Description: `Type, image, zone, security groups, bandwidth and data disks of SOURCE
+   are copied to the new instance; use flags of create-instance to override them.`,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "from-snapshot",
+			Usage: "create an image from SOURCE first so the new instance has its current disk contents",
+		},
+	}, CREATE_INSTANCE_FLAGS...),
+	Action: func(c *cli.Context) {
+		if checkValuesForBashComplete(c) {
+			return
+		}
+		if len(c.Args()) != 1 {
+			exit("Please provide one SOURCE instance.")
+		}
+		if c.String("name") == "" {
+			exit("Please provide --name.")
+		}
+		ensureInstanceOfTheSameNameDoesNotExist(c.String("name"))
+		base, groups, err := ECS_INSTANCE.CloneInstanceParamsById(getFirstPart(c.Args().First()), c.Bool("from-snapshot"))
+		if err != nil {
+			exit(err)
+		}
+		if c.IsSet("group") {
+			groups = nil
+		}
+		create, err := ECS_INSTANCE.CreateInstance(createInstanceParams(c, base))
+		if err == nil {
+			for _, group := range groups {
+				if _, err := ECS_INSTANCE.JoinSecurityGroupById(create.InstanceId, group); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to join security group %s: %s\n", group, err)
+				}
+			}
+		}
+		Print(create, err)
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "clone-instance")
+		describeInstancesForBashComplete(nil)(c)
+	},
+}
+
+// Get parameters for CreateInstance to create an instance like the one of
+// the id. Only the first security group can be set on creation, the other
+// groups are returned so the new instance can join them later.
+func (ecs *ECS) CloneInstanceParamsById(id string, fromSnapshot bool) (params map[string]string, groups []string, err error) {
+	var instance ECSInstance
+	instance, err = ecs.DescribeInstanceAttributeById(id)
+	if err != nil {
+		return
+	}
+	params = map[string]string{
+		"ImageId":                 instance.ImageId,
+		"InstanceType":            instance.InstanceType,
+		"RegionId":                instance.RegionId,
+		"ZoneId":                  instance.ZoneId,
+		"InternetMaxBandwidthIn":  fmt.Sprintf("%d", instance.InternetMaxBandwidthIn),
+		"InternetMaxBandwidthOut": fmt.Sprintf("%d", instance.InternetMaxBandwidthOut),
+	}
+	if instance.InternetChargeType != "" {
+		params["InternetChargeType"] = instance.InternetChargeType
+	}
+	if instance.VpcAttributes.VSwitchId != "" {
+		params["VSwitchId"] = instance.VpcAttributes.VSwitchId
+	}
+	groups = instance.SecurityGroupIds.SecurityGroupId
+	if len(groups) > 0 {
+		params["SecurityGroupId"] = groups[0]
+		groups = groups[1:]
+	}
+	if fromSnapshot {
+		var image ECSImage
+		name := fmt.Sprintf("%s-%s", instance.InstanceName, time.Now().Format("20060102150405"))
+		image, err = ecs.CreateImageFromInstanceAndWait(instance, name)
+		if err != nil {
+			return
+		}
+		params["ImageId"] = image.ImageId
+		n := 0
+		for _, mapping := range image.DiskDeviceMappings.DiskDeviceMapping {
+			if mapping.Type == "system" {
+				continue
+			}
+			n++
+			params[fmt.Sprintf("DataDisk.%d.SnapshotId", n)] = mapping.SnapshotId
+			params[fmt.Sprintf("DataDisk.%d.Size", n)] = mapping.Size
+		}
+		return
+	}
+	var disks ECSDisks
+	disks, _, err = ecs.DescribeDisksByInstanceId(instance.RegionId, id)
+	if err != nil {
+		return
+	}
+	n := 0
+	for _, disk := range disks {
+		if disk.Type != "data" {
+			continue
+		}
+		n++
+		params[fmt.Sprintf("DataDisk.%d.Size", n)] = fmt.Sprintf("%d", disk.Size)
+		params[fmt.Sprintf("DataDisk.%d.Category", n)] = disk.Category
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type CreateImage struct {
+	ImageId   string `json:"ImageId"`
+	RequestId string `json:"RequestId"`
+}
+
+func (ecs *ECS) CreateImageFromInstance(instance ECSInstance, name string) (resp CreateImage, _ error) {
+	return resp, ecs.Request(map[string]string{
+		"Action":     "CreateImage",
+		"RegionId":   instance.RegionId,
+		"InstanceId": instance.InstanceId,
+		"ImageName":  name,
+	}, &resp)
+}
+
+// Create an image from instance and wait until it is available.
+func (ecs *ECS) CreateImageFromInstanceAndWait(instance ECSInstance, name string) (image ECSImage, err error) {
+	var create CreateImage
+	create, err = ecs.CreateImageFromInstance(instance, name)
+	if err != nil {
+		return
+	}
+	err = waitFor(ecs.Context(), fmt.Sprintf("image %s to be available", create.ImageId), time.Hour, func() (bool, error) {
+		var err error
+		image, err = ecs.DescribeImageById(instance.RegionId, create.ImageId)
+		if err != nil {
+			return false, err
+		}
+		if image.Status == "CreateFailed" {
+			return false, fmt.Errorf("Failed to create image %s.", create.ImageId)
+		}
+		return image.Status == "Available", nil
+	})
+	return
+}
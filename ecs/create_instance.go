@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/caiguanhao/aliyun/ecs/errors"
 	"github.com/codegangsta/cli"
@@ -15,93 +17,146 @@ type CreateInstance struct {
 var DEFAULT_INCOMING_BANDWIDTH = 200
 var DEFAULT_OUTGOING_BANDWIDTH = 5
 
+var CREATE_INSTANCE_FLAGS []cli.Flag = []cli.Flag{
+	cli.StringFlag{
+		Name:  "image, i",
+		Usage: "create using this image",
+	},
+	cli.StringFlag{
+		Name:  "type, t",
+		Usage: "type of the new instance",
+	},
+	cli.StringFlag{
+		Name:  "name, n",
+		Usage: "name of the new instance",
+	},
+	cli.StringFlag{
+		Name:  "host, H",
+		Usage: "host name of the new instance, defaults to value of --name",
+	},
+	cli.StringFlag{
+		Name:  "group, g",
+		Usage: "put the new instance in to this group",
+	},
+	cli.StringFlag{
+		Name:  "region, r",
+		Usage: "put the new instance in to this region",
+	},
+	cli.StringFlag{
+		Name:  "zone, z",
+		Usage: "put the new instance in to this zone, use random zone if not specified",
+	},
+	cli.StringSliceFlag{
+		Name:  "disk, d",
+		Usage: "specify data disk size in GB ranges from 5 to 2000 (can be specified more than once; no data disk by default)",
+	},
+	cli.StringFlag{
+		Name:  "incoming-bandwidth, I",
+		Value: fmt.Sprintf("%d", DEFAULT_INCOMING_BANDWIDTH),
+		Usage: fmt.Sprintf("maximum incoming bandwidth in Mbps ranges from 1 to 200, default is %d (free in charge)", DEFAULT_INCOMING_BANDWIDTH),
+	},
+	cli.StringFlag{
+		Name:  "outgoing-bandwidth, O",
+		Value: fmt.Sprintf("%d", DEFAULT_OUTGOING_BANDWIDTH),
+		Usage: fmt.Sprintf("maximum outgoing bandwidth in Mbps ranges from 1 to 200, default is %d (pay per use)", DEFAULT_OUTGOING_BANDWIDTH),
+	},
+	cli.StringFlag{
+		Name:   "password, p",
+		Usage:  "password of the new instance, can be specified from env var",
+		EnvVar: "PASSWORD",
+	},
+}
+
+var createInstanceFlagParams = map[string]string{
+	"image":              "ImageId",
+	"type":               "InstanceType",
+	"name":               "InstanceName",
+	"host":               "HostName",
+	"group":              "SecurityGroupId",
+	"region":             "RegionId",
+	"zone":               "ZoneId",
+	"incoming-bandwidth": "InternetMaxBandwidthIn",
+	"outgoing-bandwidth": "InternetMaxBandwidthOut",
+}
+
 var CREATE_INSTANCE cli.Command = cli.Command{
 	Name:      "create-instance",
 	Aliases:   []string{"create", "c"},
 	Usage:     "create an instance",
 	ArgsUsage: " ",
-	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:  "image, i",
-			Usage: "create using this image",
-		},
-		cli.StringFlag{
-			Name:  "type, t",
-			Usage: "type of the new instance",
-		},
-		cli.StringFlag{
-			Name:  "name, n",
-			Usage: "name of the new instance",
-		},
-		cli.StringFlag{
-			Name:  "host, H",
-			Usage: "host name of the new instance, defaults to value of --name",
-		},
-		cli.StringFlag{
-			Name:  "group, g",
-			Usage: "put the new instance in to this group",
-		},
-		cli.StringFlag{
-			Name:  "region, r",
-			Usage: "put the new instance in to this region",
-		},
-		cli.StringFlag{
-			Name:  "zone, z",
-			Usage: "put the new instance in to this zone, use random zone if not specified",
-		},
-		cli.StringSliceFlag{
-			Name:  "disk, d",
-			Usage: "specify data disk size in GB ranges from 5 to 2000 (can be specified more than once; no data disk by default)",
+	Description: `With --count, --name and --host can contain {n} or {n:02} which is replaced
+   with an index continuing from the highest index of existing instances.`,
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "count, N",
+			Value: 1,
+			Usage: "number of instances to create, zones are used in turn if --zone is not specified",
 		},
-		cli.StringFlag{
-			Name:  "incoming-bandwidth, I",
-			Value: fmt.Sprintf("%d", DEFAULT_INCOMING_BANDWIDTH),
-			Usage: fmt.Sprintf("maximum incoming bandwidth in Mbps ranges from 1 to 200, default is %d (free in charge)", DEFAULT_INCOMING_BANDWIDTH),
+		cli.IntFlag{
+			Name:  "concurrency, c",
+			Value: CREATE_INSTANCES_CONCURRENCY,
+			Usage: "max number of instances to create at the same time",
 		},
-		cli.StringFlag{
-			Name:  "outgoing-bandwidth, O",
-			Value: fmt.Sprintf("%d", DEFAULT_OUTGOING_BANDWIDTH),
-			Usage: fmt.Sprintf("maximum outgoing bandwidth in Mbps ranges from 1 to 200, default is %d (pay per use)", DEFAULT_OUTGOING_BANDWIDTH),
-		},
-		cli.StringFlag{
-			Name:   "password, p",
-			Usage:  "password of the new instance, can be specified from env var",
-			EnvVar: "PASSWORD",
-		},
-	},
+	}, CREATE_INSTANCE_FLAGS...),
 	Action: func(c *cli.Context) {
 		if checkValuesForBashComplete(c) {
 			return
 		}
-		ensureInstanceOfTheSameNameDoesNotExist(c.String("name"))
-		host := c.String("host")
-		if host == "" {
-			host = c.String("name")
+		count := c.Int("count")
+		if count < 1 {
+			exit("Please provide --count of at least 1.")
 		}
-		params := map[string]string{
-			"ImageId":                 c.String("image"),
-			"InstanceType":            getFirstPart(c.String("type")),
-			"SecurityGroupId":         c.String("group"),
-			"InstanceName":            c.String("name"),
-			"HostName":                host,
-			"RegionId":                c.String("region"),
-			"ZoneId":                  c.String("zone"),
-			"Password":                c.String("password"),
-			"InternetMaxBandwidthIn":  c.String("incoming-bandwidth"),
-			"InternetMaxBandwidthOut": c.String("outgoing-bandwidth"),
-			"InternetChargeType":      "PayByTraffic",
-			"SystemDisk.Category":     "cloud",
+		if count == 1 && !hasNamePattern(c.String("name")) {
+			ensureInstanceOfTheSameNameDoesNotExist(c.String("name"))
+			Print(ECS_INSTANCE.CreateInstance(createInstanceParams(c, nil)))
+			return
 		}
-		for i, size := range c.StringSlice("disk") {
-			params[fmt.Sprintf("DataDisk.%d.Size", i+1)] = size
+		results, err := ECS_INSTANCE.CreateInstances(createInstanceParams(c, nil), count, c.Int("concurrency"))
+		Print(results, err)
+		if results.HaveError() {
+			os.Exit(1)
 		}
-		Print(ECS_INSTANCE.CreateInstance(params))
 	},
 	BashComplete: func(c *cli.Context) {
 		printFlagsForCommand(c, "create-instance")
 	},
 }
 
+// Build parameters for CreateInstance from CREATE_INSTANCE_FLAGS. If base is
+// not nil, only flags explicitly set on the command line override its values.
+func createInstanceParams(c *cli.Context, base map[string]string) map[string]string {
+	params := map[string]string{
+		"InternetChargeType":  "PayByTraffic",
+		"SystemDisk.Category": "cloud",
+	}
+	for k, v := range base {
+		params[k] = v
+	}
+	for flag, key := range createInstanceFlagParams {
+		if base == nil || c.IsSet(flag) {
+			params[key] = c.String(flag)
+		}
+	}
+	params["InstanceType"] = getFirstPart(params["InstanceType"])
+	if params["HostName"] == "" {
+		params["HostName"] = params["InstanceName"]
+	}
+	if password := c.String("password"); password != "" {
+		params["Password"] = password
+	}
+	if base == nil || c.IsSet("disk") {
+		for k := range params {
+			if strings.HasPrefix(k, "DataDisk.") {
+				delete(params, k)
+			}
+		}
+		for i, size := range c.StringSlice("disk") {
+			params[fmt.Sprintf("DataDisk.%d.Size", i+1)] = size
+		}
+	}
+	return params
+}
+
 func (ecs *ECS) CreateInstance(_params map[string]string) (resp CreateInstance, _ error) {
 	params := map[string]string{
 		"Action": "CreateInstance",
@@ -119,7 +174,7 @@ func (ecs *ECS) CreateInstance(_params map[string]string) (resp CreateInstance,
 		"RegionId":        "region",
 	} {
 		if len(params[k]) < 1 {
-			errs.Add(fmt.Sprintf("Please provide --%s.", v))
+			errs.Add(fmt.Errorf("Please provide --%s.", v))
 		}
 	}
 	if errs.HaveError() {
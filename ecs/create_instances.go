@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var namePatternRegexp = regexp.MustCompile(`\{n(?::(0?)([0-9]+))?\}`)
+
+type CreateInstanceResult struct {
+	InstanceName string
+	ZoneId       string
+	InstanceId   string
+	err          error
+}
+
+// Include error of result as JSON object like errors of other commands.
+func (result CreateInstanceResult) MarshalJSON() ([]byte, error) {
+	type plain CreateInstanceResult
+	object := struct {
+		plain
+		Error *errorObject `json:",omitempty"`
+	}{plain: plain(result)}
+	if result.err != nil {
+		level := "error"
+		if isDryRunError(result.err) {
+			level = "dry-run"
+		}
+		errObject := newErrorObject(result.err, level)
+		object.Error = &errObject
+	}
+	return json.Marshal(object)
+}
+
+type CreateInstanceResults []CreateInstanceResult
+
+// Max number of instances to create at the same time by default.
+const CREATE_INSTANCES_CONCURRENCY = 4
+
+func hasNamePattern(name string) bool {
+	return namePatternRegexp.MatchString(name)
+}
+
+// Replace every {n} or {n:02} in pattern with n.
+func expandNamePattern(pattern string, n int) string {
+	return namePatternRegexp.ReplaceAllStringFunc(pattern, func(match string) string {
+		sub := namePatternRegexp.FindStringSubmatch(match)
+		return fmt.Sprintf("%"+sub[1]+sub[2]+"d", n)
+	})
+}
+
+// Find the highest index of names matching pattern, returns 0 if none.
+func highestIndexOfNamePattern(pattern string, names []string) (highest int) {
+	parts := namePatternRegexp.Split(pattern, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	re := regexp.MustCompile("^" + strings.Join(parts, "([0-9]+)") + "$")
+	for _, name := range names {
+		match := re.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[1])
+		if err == nil && n > highest {
+			highest = n
+		}
+	}
+	return
+}
+
+// Create count instances at the same time. Names and host names are expanded
+// with indexes after the highest one of existing instances. If no zone is
+// specified, zones of the region are used one after another.
+func (ecs *ECS) CreateInstances(params map[string]string, count, concurrency int) (results CreateInstanceResults, err error) {
+	if count < 1 {
+		err = errors.New("Please provide --count of at least 1.")
+		return
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	name := params["InstanceName"]
+	if count > 1 && !hasNamePattern(name) {
+		err = errors.New("Please use {n} in --name to create more than one instance.")
+		return
+	}
+	if params["RegionId"] == "" {
+		err = errors.New("Please provide --region.")
+		return
+	}
+	var instances ECSInstances
+	instances, err = ecs.DescribeInstances()
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, instance := range instances {
+		names = append(names, instance.InstanceName)
+	}
+	start := highestIndexOfNamePattern(name, names) + 1
+	zones := []string{params["ZoneId"]}
+	if params["ZoneId"] == "" {
+		zones, err = ecs.zonesForInstances(params["RegionId"])
+		if err != nil {
+			return
+		}
+	}
+	results = make(CreateInstanceResults, count)
+	sem := make(chan bool, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		p := map[string]string{}
+		for k, v := range params {
+			p[k] = v
+		}
+		p["InstanceName"] = expandNamePattern(params["InstanceName"], start+i)
+		p["HostName"] = expandNamePattern(params["HostName"], start+i)
+		p["ZoneId"] = zones[i%len(zones)]
+		results[i].InstanceName = p["InstanceName"]
+		results[i].ZoneId = p["ZoneId"]
+		wg.Add(1)
+		go func(result *CreateInstanceResult, params map[string]string) {
+			sem <- true
+			defer func() { <-sem }()
+			resp, err := ecs.CreateInstance(params)
+			result.InstanceId = resp.InstanceId
+			result.err = err
+			wg.Done()
+		}(&results[i], p)
+	}
+	wg.Wait()
+	return
+}
+
+func (ecs *ECS) zonesForInstances(region string) (zones []string, err error) {
+	var all ECSZones
+	all, _, err = ecs.DescribeZones(region)
+	if err != nil {
+		return
+	}
+	for _, zone := range all {
+		types := zone.AvailableResourceCreation.ResourceTypes
+		available := len(types) == 0
+		for _, t := range types {
+			if t == "Instance" {
+				available = true
+			}
+		}
+		if available {
+			zones = append(zones, zone.ZoneID)
+		}
+	}
+	if len(zones) == 0 {
+		err = fmt.Errorf("No zones available for instances in %s.", region)
+	}
+	return
+}
+
+func (results CreateInstanceResults) HaveError() bool {
+	for _, result := range results {
+		if result.err != nil && !isDryRunError(result.err) {
+			return true
+		}
+	}
+	return false
+}
+
+func (results CreateInstanceResults) Print() {
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", result.InstanceName, result.err)
+			continue
+		}
+		fmt.Println(result.InstanceId)
+	}
+}
+
+func (results CreateInstanceResults) PrintTable() {
+	PrintTable(
+		/* fields     */ []interface{}{"Name", "Zone", "Result"},
+		/* showFields */ true,
+		/* listLength */ len(results),
+		/* filter     */ nil,
+		/* getInfo    */ func(i int) map[interface{}]interface{} {
+			result := results[i]
+			info := result.InstanceId
+			if result.err != nil {
+				info = strings.Replace(result.err.Error(), "\n", " ", -1)
+			}
+			return map[interface{}]interface{}{
+				"Name":   result.InstanceName,
+				"Zone":   result.ZoneId,
+				"Result": info,
+			}
+		},
+	)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testExpandNamePattern(t *testing.T, pattern string, n int, expected string) {
+	if actual := expandNamePattern(pattern, n); actual != expected {
+		t.Errorf("%s should be %s", actual, expected)
+	}
+}
+
+func TestExpandNamePattern(t *testing.T) {
+	testExpandNamePattern(t, "web", 1, "web")
+	testExpandNamePattern(t, "web-{n}", 1, "web-1")
+	testExpandNamePattern(t, "web-{n:02}", 1, "web-01")
+	testExpandNamePattern(t, "web-{n:02}", 123, "web-123")
+	testExpandNamePattern(t, "web-{n:3}", 7, "web-  7")
+	testExpandNamePattern(t, "{n}.web-{n:02}", 3, "3.web-03")
+}
+
+func testHighestIndexOfNamePattern(t *testing.T, pattern string, names []string, expected int) {
+	if actual := highestIndexOfNamePattern(pattern, names); actual != expected {
+		t.Errorf("highest index of %s should be %d instead of %d", pattern, expected, actual)
+	}
+}
+
+func TestHighestIndexOfNamePattern(t *testing.T) {
+	testHighestIndexOfNamePattern(t, "web-{n:02}", []string{}, 0)
+	testHighestIndexOfNamePattern(t, "web-{n:02}", []string{"web-01", "web-03", "db-09"}, 3)
+	testHighestIndexOfNamePattern(t, "web-{n}", []string{"web-2", "web-10", "web-x"}, 10)
+	testHighestIndexOfNamePattern(t, "web.{n}", []string{"webx5", "web.4"}, 4)
+	testHighestIndexOfNamePattern(t, "web-{n:02}-a", []string{"web-07-a", "web-08-b"}, 7)
+}
+
+func TestCreateInstancesCount(t *testing.T) {
+	for _, count := range []int{0, -1} {
+		if _, err := (&ECS{}).CreateInstances(map[string]string{"InstanceName": "web-{n}"}, count, 1); err == nil {
+			t.Errorf("count %d should be an error", count)
+		}
+	}
+}
+
+func TestCreateInstanceResultJSON(t *testing.T) {
+	results := CreateInstanceResults{
+		{InstanceName: "web-1", InstanceId: "i-1"},
+		{InstanceName: "web-2", err: &ECSResponseError{Code: "QuotaExceed.Instance", Message: "quota exceeded"}},
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := objects[0]["Error"]; ok {
+		t.Errorf("successful result should have no error: %s", data)
+	}
+	errObject, ok := objects[1]["Error"].(map[string]interface{})
+	if !ok || errObject["Code"] != "QuotaExceed.Instance" || errObject["Level"] != "error" {
+		t.Errorf("failed result should have error object: %s", data)
+	}
+	if !strings.Contains(string(data), `"InstanceName":"web-2"`) {
+		t.Errorf("result should have instance name: %s", data)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+type ECSDiskMonitorData []ECSDiskMonitorDatum
+
+func (a ECSDiskMonitorData) Len() int           { return len(a) }
+func (a ECSDiskMonitorData) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ECSDiskMonitorData) Less(i, j int) bool { return a[i].TimeStamp < a[j].TimeStamp }
+
+type ECSDiskMonitorDatum struct {
+	BPSRead   int    `json:"BPSRead"`
+	BPSTotal  int    `json:"BPSTotal"`
+	BPSWrite  int    `json:"BPSWrite"`
+	DiskID    string `json:"DiskId"`
+	IOPSRead  int    `json:"IOPSRead"`
+	IOPSTotal int    `json:"IOPSTotal"`
+	IOPSWrite int    `json:"IOPSWrite"`
+	TimeStamp string `json:"TimeStamp"`
+}
+
+type DescribeDiskMonitorData struct {
+	MonitorData struct {
+		DiskMonitorData ECSDiskMonitorData `json:"DiskMonitorData"`
+	} `json:"MonitorData"`
+	RequestID  string `json:"RequestId"`
+	TotalCount int64  `json:"TotalCount"`
+}
+
+type diskMetric struct {
+	Name  string
+	Title string
+	Value func(datum ECSDiskMonitorDatum) float64
+	Unit  string
+}
+
+var DISK_METRICS = []diskMetric{
+	{"read-bps", "Read", func(d ECSDiskMonitorDatum) float64 { return float64(d.BPSRead) }, "B/s"},
+	{"write-bps", "Write", func(d ECSDiskMonitorDatum) float64 { return float64(d.BPSWrite) }, "B/s"},
+	{"bps", "Read/Write", func(d ECSDiskMonitorDatum) float64 { return float64(d.BPSTotal) }, "B/s"},
+	{"read-iops", "Read IOPS", func(d ECSDiskMonitorDatum) float64 { return float64(d.IOPSRead) }, "IOPS"},
+	{"write-iops", "Write IOPS", func(d ECSDiskMonitorDatum) float64 { return float64(d.IOPSWrite) }, "IOPS"},
+	{"iops", "IOPS", func(d ECSDiskMonitorDatum) float64 { return float64(d.IOPSTotal) }, "IOPS"},
+}
+
+var DEFAULT_DISK_METRICS = []string{"read-bps", "write-bps", "read-iops", "write-iops"}
+
+var diskMetrics []diskMetric
+
+var DESCRIBE_DISK_MONITOR_DATA cli.Command = cli.Command{
+	Name:      "monitor-disk",
+	Aliases:   []string{"monitor-disks", "md"},
+	Usage:     "show read and write history of disks of an instance",
+	ArgsUsage: "[instance or disk IDs...]",
+	Flags: append([]cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "metric, M",
+			Usage: fmt.Sprintf("show these metrics (%s), defaults to %s", diskMetricNames(), strings.Join(DEFAULT_DISK_METRICS, ", ")),
+		},
+	}, MONITOR_FLAGS...),
+	Action: func(c *cli.Context) {
+		then, now, period := monitorTimeRange(c)
+		var err error
+		diskMetrics, err = findDiskMetrics(c.StringSlice("metric"))
+		if err != nil {
+			exit(err)
+		}
+		ForAllArgsDo([]string(c.Args()), func(arg string) {
+			if strings.HasPrefix(arg, "d-") {
+				Print(ECS_INSTANCE.DescribeDiskMonitorData(arg, then, now, period))
+				return
+			}
+			instance, err := ECS_INSTANCE.DescribeInstanceAttributeById(arg)
+			if err != nil {
+				exit(err)
+			}
+			disks, _, err := ECS_INSTANCE.DescribeDisksByInstanceId(instance.RegionId, arg)
+			if err != nil {
+				exit(err)
+			}
+			for i, disk := range disks {
+				if !IsQuiet {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("%s (%s, %s, %d GB):\n", disk.DiskId, disk.Device, disk.Type, disk.Size)
+				}
+				Print(ECS_INSTANCE.DescribeDiskMonitorData(disk.DiskId, then, now, period))
+			}
+		})
+	},
+	BashComplete: describeInstancesForBashComplete(nil),
+}
+
+func diskMetricNames() string {
+	var names []string
+	for _, metric := range DISK_METRICS {
+		names = append(names, metric.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func findDiskMetrics(names []string) (metrics []diskMetric, err error) {
+	names = splitCommas(names)
+	if len(names) == 0 {
+		names = DEFAULT_DISK_METRICS
+	}
+	for _, name := range names {
+		found := false
+		for _, metric := range DISK_METRICS {
+			if metric.Name == name {
+				metrics = append(metrics, metric)
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("Unknown metric %s, must be one of: %s.", name, diskMetricNames())
+			return
+		}
+	}
+	return
+}
+
+func (ecs *ECS) DescribeDiskMonitorData(id string, startTime, endTime time.Time, period int) (data ECSDiskMonitorData, resp DescribeDiskMonitorData, err error) {
+	windows := monitorWindows(startTime, endTime, period)
+	parts := make([]ECSDiskMonitorData, len(windows))
+	err = forMonitorWindows(windows, func(i int) error {
+		var resp DescribeDiskMonitorData
+		err := ecs.Request(map[string]string{
+			"Action":    "DescribeDiskMonitorData",
+			"DiskId":    id,
+			"StartTime": windows[i][0].Format(TIME_FORMAT),
+			"EndTime":   windows[i][1].Format(TIME_FORMAT),
+			"Period":    fmt.Sprintf("%d", period),
+		}, &resp)
+		parts[i] = resp.MonitorData.DiskMonitorData
+		return err
+	})
+	seen := map[string]bool{}
+	for _, part := range parts {
+		for _, datum := range part {
+			if seen[datum.TimeStamp] {
+				continue
+			}
+			seen[datum.TimeStamp] = true
+			data = append(data, datum)
+		}
+	}
+	sort.Sort(data)
+	resp.MonitorData.DiskMonitorData = data
+	return
+}
+
+func (data ECSDiskMonitorData) Print() {
+	for _, datum := range data {
+		fmt.Println(datum.TimeStamp)
+	}
+}
+
+func (data ECSDiskMonitorData) PrintTable() {
+	fields := []interface{}{"Time"}
+	for _, metric := range diskMetrics {
+		fields = append(fields, metric.Title)
+	}
+	PrintTable(
+		/* fields     */ fields,
+		/* showFields */ true,
+		/* listLength */ len(data),
+		/* filter     */ nil,
+		/* getInfo    */ func(i int) map[interface{}]interface{} {
+			datum := data[i]
+			t, _ := time.Parse(TIME_FORMAT, datum.TimeStamp)
+			info := map[interface{}]interface{}{
+				"Time": t.Local().Format(YMD_HMS_FORMAT),
+			}
+			for _, metric := range diskMetrics {
+				info[metric.Title] = humanValue(metric.Value(datum), metric.Unit)
+			}
+			return info
+		},
+	)
+}
@@ -0,0 +1,45 @@
+package main
+
+import "sort"
+
+type ECSDisk struct {
+	Category     string `json:"Category"`
+	CreationTime string `json:"CreationTime"`
+	Description  string `json:"Description"`
+	Device       string `json:"Device"`
+	DiskId       string `json:"DiskId"`
+	DiskName     string `json:"DiskName"`
+	InstanceId   string `json:"InstanceId"`
+	RegionId     string `json:"RegionId"`
+	Size         int64  `json:"Size"`
+	Status       string `json:"Status"`
+	Type         string `json:"Type"`
+	ZoneId       string `json:"ZoneId"`
+}
+
+type ECSDisks []ECSDisk
+
+func (a ECSDisks) Len() int           { return len(a) }
+func (a ECSDisks) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ECSDisks) Less(i, j int) bool { return a[i].Device < a[j].Device }
+
+type DescribeDisks struct {
+	Disks struct {
+		Disk ECSDisks `json:"Disk"`
+	} `json:"Disks"`
+	PageNumber int64  `json:"PageNumber"`
+	PageSize   int64  `json:"PageSize"`
+	RequestId  string `json:"RequestId"`
+	TotalCount int64  `json:"TotalCount"`
+}
+
+func (ecs *ECS) DescribeDisksByInstanceId(region, id string) (disks ECSDisks, resp DescribeDisks, _ error) {
+	defer func() {
+		sort.Sort(disks)
+	}()
+	return resp.Disks.Disk, resp, ecs.Request(map[string]string{
+		"Action":     "DescribeDisks",
+		"RegionId":   region,
+		"InstanceId": id,
+	}, &resp)
+}
@@ -16,6 +16,7 @@ type ECSImage struct {
 			Device     string `json:"Device"`
 			Size       string `json:"Size"`
 			SnapshotId string `json:"SnapshotId"`
+			Type       string `json:"Type"`
 		} `json:"DiskDeviceMapping"`
 	} `json:"DiskDeviceMappings"`
 	ImageId         string `json:"ImageId"`
@@ -25,7 +26,9 @@ type ECSImage struct {
 	IsSubscribed    bool   `json:"IsSubscribed"`
 	OSName          string `json:"OSName"`
 	ProductCode     string `json:"ProductCode"`
+	Progress        string `json:"Progress"`
 	Size            int64  `json:"Size"`
+	Status          string `json:"Status"`
 }
 
 var DESCRIBE_IMAGES cli.Command = cli.Command{
@@ -65,6 +68,24 @@ func (ecs *ECS) DescribeImages() (images ECSImages, resp DescribeImages, _ error
 	}, &resp)
 }
 
+func (ecs *ECS) DescribeImageById(region, id string) (image ECSImage, err error) {
+	var resp DescribeImages
+	err = ecs.Request(map[string]string{
+		"Action":   "DescribeImages",
+		"RegionId": region,
+		"ImageId":  id,
+	}, &resp)
+	if err != nil {
+		return
+	}
+	if len(resp.Images.Image) < 1 {
+		err = fmt.Errorf("Image %s not found.", id)
+		return
+	}
+	image = resp.Images.Image[0]
+	return
+}
+
 func (images ECSImages) Print() {
 	for _, image := range images {
 		fmt.Println(image.ImageId)
@@ -19,6 +19,11 @@ func (ipaddr DescribeInstanceAttributeIPAddress) GetIPAddress(n int) string {
 	return ipaddr.IpAddress[n]
 }
 
+type ECSInstanceTag struct {
+	TagKey   string `json:"TagKey"`
+	TagValue string `json:"TagValue"`
+}
+
 type DescribeInstanceAttribute struct {
 	ClusterId    string `json:"ClusterId"`
 	CreationTime string `json:"CreationTime"`
@@ -48,7 +53,10 @@ type DescribeInstanceAttribute struct {
 	SecurityGroupIds struct {
 		SecurityGroupId []string `json:"SecurityGroupId"`
 	} `json:"SecurityGroupIds"`
-	Status        string `json:"Status"`
+	Status string `json:"Status"`
+	Tags   struct {
+		Tag []ECSInstanceTag `json:"Tag"`
+	} `json:"Tags"`
 	VlanId        string `json:"VlanId"`
 	VpcAttributes struct {
 		NatIpAddress     string                             `json:"NatIpAddress"`
@@ -66,6 +74,22 @@ func (ecs *ECS) DescribeInstanceAttributeById(id string) (instance ECSInstance,
 	}, &instance)
 }
 
+// Get public IP address or elastic IP address of instance.
+func (instance ECSInstance) PublicIP() string {
+	if ip := instance.PublicIpAddress.GetIPAddress(0); ip != "" {
+		return ip
+	}
+	return instance.EipAddress.IpAddress
+}
+
+// Get private IP address of classic or VPC instance.
+func (instance ECSInstance) PrivateIP() string {
+	if ip := instance.InnerIpAddress.GetIPAddress(0); ip != "" {
+		return ip
+	}
+	return instance.VpcAttributes.PrivateIpAddress.GetIPAddress(0)
+}
+
 func (instance ECSInstance) Print() {
 	fmt.Println(instance.InstanceId)
 }
@@ -80,7 +104,8 @@ func (instance ECSInstance) PrintTable() {
 	fmt.Printf(format, "ID", instance.InstanceId)
 	fmt.Printf(format, "Name", instance.InstanceName)
 	fmt.Printf(format, "Type", instance.InstanceType)
-	specs := (<-typesMapChan)[instance.InstanceType]
+	<-typesMapReady
+	specs := typesMap[instance.InstanceType]
 	if specs == "" {
 		specs = "unknown"
 	}
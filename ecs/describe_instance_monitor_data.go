@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -9,6 +12,10 @@ import (
 
 type ECSInstanceMonitorData []ECSInstanceMonitorDatum
 
+func (a ECSInstanceMonitorData) Len() int           { return len(a) }
+func (a ECSInstanceMonitorData) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ECSInstanceMonitorData) Less(i, j int) bool { return a[i].TimeStamp < a[j].TimeStamp }
+
 type ECSInstanceMonitorDatum struct {
 	BPSRead           int    `json:"BPSRead"`
 	BPSWrite          int    `json:"BPSWrite"`
@@ -34,53 +41,271 @@ type DescribeInstanceMonitorData struct {
 	RequestID string `json:"RequestId"`
 }
 
+type instanceMetric struct {
+	Name  string
+	Title string
+	// value of the datum in its unit: percent, bytes per second or counts
+	// per second; traffic in kbits is divided by the period
+	Value func(datum ECSInstanceMonitorDatum, period int) float64
+	Unit  string
+}
+
+func kbitsPerPeriod(kbits, period int) float64 {
+	return float64(kbits) * 1024 / 8 / float64(period)
+}
+
+var INSTANCE_METRICS = []instanceMetric{
+	{"cpu", "CPU Usage", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.CPU) }, "%"},
+	{"net-in", "Received", func(d ECSInstanceMonitorDatum, p int) float64 { return kbitsPerPeriod(d.InternetRX, p) }, "B/s"},
+	{"net-out", "Sent", func(d ECSInstanceMonitorDatum, p int) float64 { return kbitsPerPeriod(d.InternetTX, p) }, "B/s"},
+	{"net-bandwidth", "Bandwidth", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.InternetBandwidth) * 1024 / 8 }, "B/s"},
+	{"intranet-in", "Intranet Received", func(d ECSInstanceMonitorDatum, p int) float64 { return kbitsPerPeriod(d.IntranetRX, p) }, "B/s"},
+	{"intranet-out", "Intranet Sent", func(d ECSInstanceMonitorDatum, p int) float64 { return kbitsPerPeriod(d.IntranetTX, p) }, "B/s"},
+	{"intranet-bandwidth", "Intranet Bandwidth", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.IntranetBandwidth) * 1024 / 8 }, "B/s"},
+	{"disk-read-bps", "Disk Read", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.BPSRead) }, "B/s"},
+	{"disk-write-bps", "Disk Write", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.BPSWrite) }, "B/s"},
+	{"disk-bps", "Disk Read/Write", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.BPSRead + d.BPSWrite) }, "B/s"},
+	{"disk-read-iops", "Disk Read IOPS", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.IOPSRead) }, "IOPS"},
+	{"disk-write-iops", "Disk Write IOPS", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.IOPSWrite) }, "IOPS"},
+	{"disk-iops", "Disk IOPS", func(d ECSInstanceMonitorDatum, p int) float64 { return float64(d.IOPSRead + d.IOPSWrite) }, "IOPS"},
+}
+
+var DEFAULT_INSTANCE_METRICS = []string{"cpu", "net-in", "net-out"}
+
 var period int
+var instanceMetrics []instanceMetric
+
+var MONITOR_FLAGS = []cli.Flag{
+	cli.IntFlag{
+		Name:  "hours, H",
+		Value: 1,
+		Usage: "show stats from how many hours ago till now",
+	},
+	cli.IntFlag{
+		Name:  "period, p",
+		Value: 0,
+		Usage: "period in seconds; must be: 60, 600 or 3600; otherwise will use smallest period that needs only one request",
+	},
+	cli.StringFlag{
+		Name:  "from",
+		Usage: "show stats from this time (like 2006-01-02 15:04:05 in local time or RFC3339), overrides --hours",
+	},
+	cli.StringFlag{
+		Name:  "to",
+		Usage: "show stats till this time, defaults to now",
+	},
+}
 
 var DESCRIBE_INSTANCE_MONITOR_DATA cli.Command = cli.Command{
 	Name:      "monitor-instance",
 	Aliases:   []string{"monitor", "m"},
-	Usage:     "show CPU and network usage history of an instance",
+	Usage:     "show CPU, network and disk usage history of an instance",
 	ArgsUsage: "[instance IDs...]",
-	Flags: []cli.Flag{
-		cli.IntFlag{
-			Name:  "hours, H",
-			Value: 1,
-			Usage: "show stats from how many hours ago till now",
+	Flags: append([]cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "metric, M",
+			Usage: fmt.Sprintf("show these metrics (%s), defaults to %s", instanceMetricNames(), strings.Join(DEFAULT_INSTANCE_METRICS, ", ")),
 		},
-		cli.IntFlag{
-			Name:  "period, p",
-			Value: 0,
-			Usage: "period in seconds; must be: 60, 600 or 3600; otherwise will use smaller period as possible",
+		cli.BoolFlag{
+			Name:  "chart, C",
+			Usage: "draw line charts of metrics, instances are drawn in the same chart",
 		},
-	},
+		cli.BoolFlag{
+			Name:  "sparkline, s",
+			Usage: "draw sparklines of metrics",
+		},
+	}, append(watchFlags("refresh latest stats", 60), MONITOR_FLAGS...)...),
 	Action: func(c *cli.Context) {
-		now := time.Now().UTC()
-		then := now.Add(time.Duration(-1*c.Int("hours")) * time.Hour)
-		period = c.Int("period")
-		if period != 60 && period != 600 && period != 3600 {
-			if now.Sub(then).Seconds()/60 <= 400 {
-				period = 60
-			} else if now.Sub(then).Seconds()/600 <= 400 {
-				period = 600
-			} else {
-				period = 3600
+		var err error
+		instanceMetrics, err = findInstanceMetrics(c.StringSlice("metric"))
+		if err != nil {
+			exit(err)
+		}
+		args := []string(c.Args())
+		charts := c.Bool("chart") || c.Bool("sparkline")
+		names := map[string]string{}
+		if interval := watchInterval(c); interval > 0 {
+			lastTimeStamps := map[string]string{}
+			watch(interval, func(inPlace bool) {
+				var then, now time.Time
+				then, now, period = monitorTimeRange(c)
+				if charts {
+					series, err := ECS_INSTANCE.describeInstanceMonitorSeries(args, then, now, names)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err)
+						return
+					}
+					printInstanceMonitorCharts(series, c.Bool("sparkline"))
+					return
+				}
+				ForAllArgsDo(args, func(arg string) {
+					data, _, err := ECS_INSTANCE.DescribeInstanceMonitorData(arg, then, now, period)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, arg+":", err)
+						return
+					}
+					if !inPlace {
+						last, seen := lastTimeStamps[arg]
+						data = data.After(last)
+						if len(data) == 0 {
+							return
+						}
+						lastTimeStamps[arg] = data[len(data)-1].TimeStamp
+						if len(args) > 1 {
+							fmt.Printf("%s:\n", arg)
+						}
+						data.printTable(!seen)
+						return
+					}
+					if len(args) > 1 {
+						fmt.Printf("%s:\n", arg)
+					}
+					data.PrintTable()
+				})
+			})
+			return
+		}
+		var then, now time.Time
+		then, now, period = monitorTimeRange(c)
+		if charts {
+			series, err := ECS_INSTANCE.describeInstanceMonitorSeries(args, then, now, names)
+			if err != nil {
+				exit(err)
 			}
+			printInstanceMonitorCharts(series, c.Bool("sparkline"))
+			return
 		}
-		ForAllArgsDo([]string(c.Args()), func(arg string) {
+		ForAllArgsDo(args, func(arg string) {
 			Print(ECS_INSTANCE.DescribeInstanceMonitorData(arg, then, now, period))
 		})
 	},
 	BashComplete: describeInstancesForBashComplete(nil),
 }
 
-func (ecs *ECS) DescribeInstanceMonitorData(id string, startTime, endTime time.Time, period int) (_ ECSInstanceMonitorData, resp DescribeInstanceMonitorData, err error) {
-	return resp.MonitorData.InstanceMonitorData, resp, ecs.Request(map[string]string{
-		"Action":     "DescribeInstanceMonitorData",
-		"InstanceId": id,
-		"StartTime":  startTime.Format(TIME_FORMAT),
-		"EndTime":    endTime.Format(TIME_FORMAT),
-		"Period":     fmt.Sprintf("%d", period),
-	}, &resp)
+// Get time range and period from MONITOR_FLAGS. If period is invalid, choose
+// the smallest one that doesn't exceed the limit of datapoints per request.
+func monitorTimeRange(c *cli.Context) (then, now time.Time, period int) {
+	var err error
+	now = time.Now().UTC()
+	if c.String("to") != "" {
+		if now, err = parseTime(c.String("to")); err != nil {
+			exit(err)
+		}
+	}
+	then = now.Add(time.Duration(-1*c.Int("hours")) * time.Hour)
+	if c.String("from") != "" {
+		if then, err = parseTime(c.String("from")); err != nil {
+			exit(err)
+		}
+	}
+	if !then.Before(now) {
+		exit("Start time must be earlier than end time.")
+	}
+	period = c.Int("period")
+	if period != 60 && period != 600 && period != 3600 {
+		if now.Sub(then).Seconds()/60 <= MONITOR_DATA_LIMIT {
+			period = 60
+		} else if now.Sub(then).Seconds()/600 <= MONITOR_DATA_LIMIT {
+			period = 600
+		} else {
+			period = 3600
+		}
+	}
+	return
+}
+
+func instanceMetricNames() string {
+	var names []string
+	for _, metric := range INSTANCE_METRICS {
+		names = append(names, metric.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Find metrics by names, names can also be separated by commas.
+func findInstanceMetrics(names []string) (metrics []instanceMetric, err error) {
+	names = splitCommas(names)
+	if len(names) == 0 {
+		names = DEFAULT_INSTANCE_METRICS
+	}
+	for _, name := range names {
+		found := false
+		for _, metric := range INSTANCE_METRICS {
+			if metric.Name == name {
+				metrics = append(metrics, metric)
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("Unknown metric %s, must be one of: %s.", name, instanceMetricNames())
+			return
+		}
+	}
+	return
+}
+
+// Get monitor data of the instance. Time range that has more datapoints than
+// the limit is split into several requests and the results are merged.
+func (ecs *ECS) DescribeInstanceMonitorData(id string, startTime, endTime time.Time, period int) (data ECSInstanceMonitorData, resp DescribeInstanceMonitorData, err error) {
+	windows := monitorWindows(startTime, endTime, period)
+	parts := make([]ECSInstanceMonitorData, len(windows))
+	err = forMonitorWindows(windows, func(i int) error {
+		var resp DescribeInstanceMonitorData
+		err := ecs.Request(map[string]string{
+			"Action":     "DescribeInstanceMonitorData",
+			"InstanceId": id,
+			"StartTime":  windows[i][0].Format(TIME_FORMAT),
+			"EndTime":    windows[i][1].Format(TIME_FORMAT),
+			"Period":     fmt.Sprintf("%d", period),
+		}, &resp)
+		parts[i] = resp.MonitorData.InstanceMonitorData
+		return err
+	})
+	seen := map[string]bool{}
+	for _, part := range parts {
+		for _, datum := range part {
+			if seen[datum.TimeStamp] {
+				continue
+			}
+			seen[datum.TimeStamp] = true
+			data = append(data, datum)
+		}
+	}
+	sort.Sort(data)
+	resp.MonitorData.InstanceMonitorData = data
+	return
+}
+
+// Get monitor data of instances of the ids along with their names, names
+// are cached in the names map.
+func (ecs *ECS) describeInstanceMonitorSeries(ids []string, startTime, endTime time.Time, names map[string]string) (series []instanceMonitorSeries, err error) {
+	for _, arg := range ids {
+		id := getFirstPart(arg)
+		var data ECSInstanceMonitorData
+		data, _, err = ecs.DescribeInstanceMonitorData(id, startTime, endTime, period)
+		if err != nil {
+			return
+		}
+		if _, ok := names[id]; !ok {
+			names[id] = id
+			if instance, err := ecs.DescribeInstanceAttributeById(id); err == nil {
+				names[id] = instance.InstanceName
+			}
+		}
+		series = append(series, instanceMonitorSeries{names[id], data})
+	}
+	return
+}
+
+// Get datapoints after the time stamp.
+func (data ECSInstanceMonitorData) After(timeStamp string) (ret ECSInstanceMonitorData) {
+	for _, datum := range data {
+		if datum.TimeStamp > timeStamp {
+			ret = append(ret, datum)
+		}
+	}
+	return
 }
 
 func (data ECSInstanceMonitorData) Print() {
@@ -90,20 +315,29 @@ func (data ECSInstanceMonitorData) Print() {
 }
 
 func (data ECSInstanceMonitorData) PrintTable() {
+	data.printTable(true)
+}
+
+func (data ECSInstanceMonitorData) printTable(showFields bool) {
+	fields := []interface{}{"Time"}
+	for _, metric := range instanceMetrics {
+		fields = append(fields, metric.Title)
+	}
 	PrintTable(
-		/* fields     */ []interface{}{"Time", "CPU Usage", "Received", "Sent"},
-		/* showFields */ true,
+		/* fields     */ fields,
+		/* showFields */ showFields,
 		/* listLength */ len(data),
 		/* filter     */ nil,
 		/* getInfo    */ func(i int) map[interface{}]interface{} {
 			datum := data[i]
 			t, _ := time.Parse(TIME_FORMAT, datum.TimeStamp)
-			return map[interface{}]interface{}{
-				"Time":      t.Local().Format(YMD_HMS_FORMAT),
-				"CPU Usage": fmt.Sprintf("%d%%", datum.CPU),
-				"Received":  fmt.Sprintf("%d KB/s", datum.InternetRX/8/period),
-				"Sent":      fmt.Sprintf("%d KB/s", datum.InternetTX/8/period),
+			info := map[interface{}]interface{}{
+				"Time": t.Local().Format(YMD_HMS_FORMAT),
+			}
+			for _, metric := range instanceMetrics {
+				info[metric.Title] = humanValue(metric.Value(datum, period), metric.Unit)
 			}
+			return info
 		},
 	)
 }
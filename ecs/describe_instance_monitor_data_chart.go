@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const chartHeight = 10
+
+type instanceMonitorSeries struct {
+	Name string
+	Data ECSInstanceMonitorData
+}
+
+func metricSummary(values []float64, unit string) string {
+	min, avg, max, p95 := summarize(values)
+	return fmt.Sprintf("min %s  avg %s  max %s  p95 %s",
+		humanValue(min, unit), humanValue(avg, unit), humanValue(max, unit), humanValue(p95, unit))
+}
+
+// Print a chart for each metric in instanceMetrics with series of every
+// instance, or sparklines if sparklines is true.
+func printInstanceMonitorCharts(series []instanceMonitorSeries, sparklines bool) {
+	width := terminalWidth()
+	color := isTerminal(int(os.Stdout.Fd()))
+	nameWidth := 0
+	for _, s := range series {
+		if l := utf8.RuneCountInString(s.Name); l > nameWidth {
+			nameWidth = l
+		}
+	}
+	for m, metric := range instanceMetrics {
+		if m > 0 {
+			fmt.Println()
+		}
+		fmt.Println(metric.Title)
+		values := make([][]float64, len(series))
+		max := 0.0
+		if metric.Unit == "%" {
+			max = 100
+		}
+		var summaries []string
+		summaryWidth := 0
+		for i, s := range series {
+			for _, datum := range s.Data {
+				value := metric.Value(datum, period)
+				values[i] = append(values[i], value)
+				if value > max {
+					max = value
+				}
+			}
+			summary := metricSummary(values[i], metric.Unit)
+			summaries = append(summaries, summary)
+			if len(summary) > summaryWidth {
+				summaryWidth = len(summary)
+			}
+		}
+		if sparklines {
+			lineWidth := width - nameWidth - summaryWidth - 4
+			if lineWidth < 10 {
+				lineWidth = 10
+			}
+			for i, s := range series {
+				line := sparkline(values[i], lineWidth, 0, max)
+				line += strings.Repeat(" ", lineWidth-utf8.RuneCountInString(line))
+				fmt.Printf("%-*s  %s  %s\n", nameWidth, s.Name, line, summaries[i])
+			}
+			continue
+		}
+		top, bottom := humanValue(max, metric.Unit), humanValue(0, metric.Unit)
+		labelWidth := len(top)
+		if len(bottom) > labelWidth {
+			labelWidth = len(bottom)
+		}
+		chartWidth := width - labelWidth - 2
+		if chartWidth < 10 {
+			chartWidth = 10
+		}
+		for y, line := range brailleChart(values, chartWidth, chartHeight, 0, max, color) {
+			label := ""
+			if y == 0 {
+				label = top
+			} else if y == chartHeight-1 {
+				label = bottom
+			}
+			fmt.Printf("%*s │%s\n", labelWidth, label, line)
+		}
+		if len(series) > 0 && len(series[0].Data) > 0 {
+			data := series[0].Data
+			from, _ := time.Parse(TIME_FORMAT, data[0].TimeStamp)
+			to, _ := time.Parse(TIME_FORMAT, data[len(data)-1].TimeStamp)
+			fromStr, toStr := from.Local().Format(YMD_HMS_FORMAT), to.Local().Format(YMD_HMS_FORMAT)
+			gap := chartWidth - len(fromStr) - len(toStr)
+			if gap < 1 {
+				gap = 1
+			}
+			fmt.Printf("%*s  %s%s%s\n", labelWidth, "", fromStr, strings.Repeat(" ", gap), toStr)
+		}
+		for i, s := range series {
+			mark := "■"
+			if color {
+				mark = chartColors[i%len(chartColors)] + mark + colorReset
+			}
+			fmt.Printf("%*s  %s %-*s  %s\n", labelWidth, "", mark, nameWidth, s.Name, summaries[i])
+		}
+	}
+}
@@ -54,6 +54,19 @@ func (ecs *ECS) DescribeInstanceTypes() (types ECSInstanceTypes, resp DescribeIn
 	}, &resp)
 }
 
+func (itype ECSInstanceType) Specs() string {
+	return fmt.Sprintf("%d CPU, %.6gG Mem", itype.CpuCoreCount, itype.MemorySize)
+}
+
+func (types ECSInstanceTypes) Find(id string) (itype ECSInstanceType, ok bool) {
+	for _, itype = range types {
+		if itype.InstanceTypeId == id {
+			return itype, true
+		}
+	}
+	return ECSInstanceType{}, false
+}
+
 func (types ECSInstanceTypes) Print() {
 	for _, itype := range types {
 		fmt.Println(itype.InstanceTypeId)
@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"math"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -44,14 +46,18 @@ var makeHosts bool
 var usePrivateIPAddr bool
 var showRawType bool
 
-var typesMapChan chan map[string]string = make(chan map[string]string)
+var changedStatuses map[string]bool
+
+// Specs of instance types, read after typesMapReady is closed.
+var typesMap map[string]string
+var typesMapReady = make(chan bool)
 
 var DESCRIBE_INSTANCES cli.Command = cli.Command{
 	Name:      "list-instances",
 	Aliases:   []string{"list", "ls", "l"},
 	Usage:     "list all ECS instances of all regions",
 	ArgsUsage: "[instance IDs...]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		cli.BoolFlag{
 			Name:        "all, a",
 			Usage:       "also show hidden instances, overrides --hidden-only, but not --regex",
@@ -85,7 +91,7 @@ var DESCRIBE_INSTANCES cli.Command = cli.Command{
 			Usage:       "show raw instance type instead of specs",
 			Destination: &showRawType,
 		},
-	},
+	}, watchFlags("refresh the list and highlight status changes", 5)...),
 	Action: func(c *cli.Context) {
 		for _, regex := range c.StringSlice("regex") {
 			re, err := regexp.Compile(regex)
@@ -98,15 +104,20 @@ var DESCRIBE_INSTANCES cli.Command = cli.Command{
 			customFields = append(customFields, field)
 		}
 		go func() {
-			typesMap := map[string]string{}
+			specs := map[string]string{}
 			if !showRawType {
 				types, _, _ := ECS_INSTANCE.DescribeInstanceTypes()
 				for _, _type := range types {
-					typesMap[_type.InstanceTypeId] = fmt.Sprintf("%d CPU, %.6gG Mem", _type.CpuCoreCount, _type.MemorySize)
+					specs[_type.InstanceTypeId] = _type.Specs()
 				}
 			}
-			typesMapChan <- typesMap
+			typesMap = specs
+			close(typesMapReady)
 		}()
+		if interval := watchInterval(c); interval > 0 {
+			watchInstances(interval, []string(c.Args()))
+			return
+		}
 		if c.Args().Present() {
 			ForAllArgsDo([]string(c.Args()), func(arg string) {
 				Print(ECS_INSTANCE.DescribeInstanceAttributeById(arg))
@@ -122,6 +133,7 @@ var DESCRIBE_INSTANCES cli.Command = cli.Command{
 }
 
 func (ecs *ECS) DescribeInstances() (instances ECSInstances, err error) {
+	var mutex sync.Mutex
 	err = ForAllRegionsDo(func(region string) (err error) {
 		var resp DescribeInstances
 		err = ecs.Request(map[string]string{
@@ -129,7 +141,9 @@ func (ecs *ECS) DescribeInstances() (instances ECSInstances, err error) {
 			"RegionId": region,
 		}, &resp)
 		if err == nil {
+			mutex.Lock()
 			instances = append(instances, resp.Instances.Instance...)
+			mutex.Unlock()
 		}
 		return
 	})
@@ -147,7 +161,7 @@ func (instances ECSInstances) Print() {
 }
 
 func (instances ECSInstances) PrintTable() {
-	typesMap := <-typesMapChan
+	<-typesMapReady
 
 	var fields []interface{}
 	var showFields bool
@@ -190,10 +204,14 @@ func (instances ECSInstances) PrintTable() {
 		},
 		/* getInfo    */ func(i int) map[interface{}]interface{} {
 			instance := instances[i]
+			status := instance.Status
+			if changedStatuses[instance.InstanceId] {
+				status = highlightStart + status + highlightEnd
+			}
 			return map[interface{}]interface{}{
 				"ID":          instance.InstanceId,
 				"Name":        instance.InstanceName,
-				"Status":      instance.Status,
+				"Status":      status,
 				"Public IP":   instance.PublicIpAddress.GetIPAddress(0),
 				"Private IP":  instance.InnerIpAddress.GetIPAddress(0),
 				"Specs":       typesMap[instance.InstanceType],
@@ -205,6 +223,67 @@ func (instances ECSInstances) PrintTable() {
 	)
 }
 
+// Refresh the instance list every interval seconds, instances are filtered by
+// ids if any. Status changes are highlighted in the list if stdout is a
+// terminal; otherwise only the changes are printed after the first list.
+func watchInstances(interval int, ids []string) {
+	var lastStatuses map[string]string
+	watch(interval, func(inPlace bool) {
+		all, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		var instances ECSInstances
+		for _, instance := range all {
+			found := len(ids) == 0
+			for _, id := range ids {
+				if getFirstPart(id) == instance.InstanceId {
+					found = true
+				}
+			}
+			if found {
+				instances = append(instances, instance)
+			}
+		}
+		statuses := map[string]string{}
+		changedStatuses = map[string]bool{}
+		for _, instance := range instances {
+			statuses[instance.InstanceId] = instance.Status
+			if last, ok := lastStatuses[instance.InstanceId]; ok && last != instance.Status {
+				changedStatuses[instance.InstanceId] = true
+			}
+		}
+		if inPlace || lastStatuses == nil {
+			if IsQuiet {
+				instances.Print()
+			} else {
+				instances.PrintTable()
+			}
+		} else {
+			now := time.Now().Format(YMD_HMS_FORMAT)
+			for _, instance := range instances {
+				if !shouldShow(instance) {
+					continue
+				}
+				last, ok := lastStatuses[instance.InstanceId]
+				if !ok {
+					last = "(new)"
+				}
+				if last != instance.Status {
+					fmt.Printf("%s  %s  %s  %s -> %s\n", now, instance.InstanceId, instance.InstanceName, last, instance.Status)
+				}
+			}
+			for id := range lastStatuses {
+				if _, ok := statuses[id]; !ok {
+					fmt.Printf("%s  %s  %s -> (removed)\n", now, id, lastStatuses[id])
+				}
+			}
+		}
+		lastStatuses = statuses
+	})
+}
+
 func dateStr(input string) (output string) {
 	createdAt, _ := time.Parse(instanceDateTimeFormat, input)
 	output = fmt.Sprintf("%s (%.0f days ago)",
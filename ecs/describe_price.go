@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+type DescribePrice struct {
+	PriceInfo struct {
+		Price struct {
+			Currency      string  `json:"Currency"`
+			DiscountPrice float64 `json:"DiscountPrice"`
+			OriginalPrice float64 `json:"OriginalPrice"`
+			TradePrice    float64 `json:"TradePrice"`
+		} `json:"Price"`
+	} `json:"PriceInfo"`
+	RequestId string `json:"RequestId"`
+}
+
+// Get pay-as-you-go price per hour of instance type in region.
+func (ecs *ECS) DescribeInstanceTypePrice(region, typeId string) (price float64, currency string, err error) {
+	var resp DescribePrice
+	err = ecs.Request(map[string]string{
+		"Action":       "DescribePrice",
+		"RegionId":     region,
+		"ResourceType": "instance",
+		"InstanceType": typeId,
+		"PriceUnit":    "Hour",
+	}, &resp)
+	if err != nil {
+		return
+	}
+	price, currency = resp.PriceInfo.Price.TradePrice, resp.PriceInfo.Price.Currency
+	if currency == "" {
+		err = fmt.Errorf("No price of %s in %s.", typeId, region)
+	}
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/codegangsta/cli"
 )
@@ -122,6 +123,7 @@ func (a ECSRegionsAndZones) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ECSRegionsAndZones) Less(i, j int) bool { return a[i].RegionName < a[j].RegionName }
 
 func (ecs *ECS) DescribeRegionsAndZones() (regionsNzones ECSRegionsAndZones, err error) {
+	var mutex sync.Mutex
 	err = ForAllRegionsDo(func(region string) (err error) {
 		var zones ECSZones
 		zones, _, err = ecs.DescribeZones(region)
@@ -134,7 +136,9 @@ func (ecs *ECS) DescribeRegionsAndZones() (regionsNzones ECSRegionsAndZones, err
 			for _, zone := range zones {
 				rNz.Zones = append(rNz.Zones, zone.ZoneID)
 			}
+			mutex.Lock()
 			regionsNzones = append(regionsNzones, rNz)
+			mutex.Unlock()
 		}
 		return
 	})
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/codegangsta/cli"
 )
@@ -42,6 +43,7 @@ type DescribeSecurityGroups struct {
 }
 
 func (ecs *ECS) DescribeSecurityGroups() (groups ECSSecurityGroups, err error) {
+	var mutex sync.Mutex
 	err = ForAllRegionsDo(func(region string) (err error) {
 		var resp DescribeSecurityGroups
 		err = ecs.Request(map[string]string{
@@ -49,10 +51,12 @@ func (ecs *ECS) DescribeSecurityGroups() (groups ECSSecurityGroups, err error) {
 			"RegionId": region,
 		}, &resp)
 		if err == nil {
+			mutex.Lock()
 			for _, group := range resp.SecurityGroups.SecurityGroup {
 				group.regionId = region
 				groups = append(groups, group)
 			}
+			mutex.Unlock()
 		}
 		return
 	})
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/caiguanhao/aliyun/redact"
+)
+
+// With --dry-run, mutating actions are not performed. Actions that support
+// the DryRun parameter are sent to validate parameters and permissions,
+// others are printed instead of sent.
+var IsDryRun bool
+
+var DRY_RUN_ACTIONS = map[string]bool{
+	"CreateInstance":     true,
+	"RunInstances":       true,
+	"StartInstance":      true,
+	"StopInstance":       true,
+	"RebootInstance":     true,
+	"DeleteInstance":     true,
+	"ModifyInstanceSpec": true,
+}
+
+const DRY_RUN_CODE = "DryRunOperation"
+
+// Code of error when the server performs the action despite DryRun.
+const DRY_RUN_IGNORED_CODE = "DryRunIgnored"
+
+func isDryRunError(err error) bool {
+	ecsErr, ok := err.(*ECSResponseError)
+	return ok && ecsErr.Code == DRY_RUN_CODE
+}
+
+func (ecs *ECS) dryRun(ctx context.Context, queries map[string]string, target interface{}) error {
+	action := queries["Action"]
+	if DRY_RUN_ACTIONS[action] {
+		dryRunQueries := map[string]string{"DryRun": "true"}
+		for key, value := range queries {
+			dryRunQueries[key] = value
+		}
+		req, err := ecs.newRequest(dryRunQueries)
+		if err == nil {
+			err = sendRequest(ctx, req, target)
+		}
+		err = withRequestContext(err, queries)
+		if err == nil {
+			// the server ignored DryRun and the action was really performed
+			recordRequest(queries, target, nil)
+			fmt.Fprintf(os.Stderr, "WARNING: DryRun is ignored, %s WAS PERFORMED.\n", action)
+			err = withRequestContext(&ECSResponseError{
+				Code:    DRY_RUN_IGNORED_CODE,
+				Message: fmt.Sprintf("DryRun is ignored and %s was performed.", action),
+			}, queries)
+		}
+		return err
+	}
+	req, err := ecs.newRequest(queries)
+	if err != nil {
+		return withRequestContext(err, queries)
+	}
+	params, err := requestParams(req)
+	if err != nil {
+		return withRequestContext(err, queries)
+	}
+	params = redact.Params(params)
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// stdout is kept for results like those of --output json
+	fmt.Fprintf(os.Stderr, "%s does not support dry run, request is not sent:\n", action)
+	for _, key := range keys {
+		fmt.Fprintf(os.Stderr, "  %s=%s\n", key, params[key])
+	}
+	return withRequestContext(&ECSResponseError{Code: DRY_RUN_CODE, Message: "Request is not sent."}, queries)
+}
+
+// Get parameters of request from its query string, form body and signed
+// headers.
+func requestParams(req *http.Request) (map[string]string, error) {
+	params := map[string]string{}
+	for key, values := range req.URL.Query() {
+		params[key] = values[0]
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range form {
+			params[key] = values[0]
+		}
+	}
+	for key, values := range req.Header {
+		params[strings.ToLower(key)] = values[0]
+	}
+	return params, nil
+}
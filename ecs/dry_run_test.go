@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/aliyun/journal"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDryRunIgnored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "journal.jsonl")
+	os.Setenv("ALIYUN_JOURNAL", file)
+	defer os.Unsetenv("ALIYUN_JOURNAL")
+
+	oldTransport := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// a server that ignores DryRun and performs the action
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"RequestId":"request-1"}`)),
+		}, nil
+	})
+	defer func() {
+		http.DefaultTransport = oldTransport
+	}()
+
+	var target map[string]interface{}
+	err = (&ECS{}).dryRun(context.Background(), map[string]string{"Action": "StopInstance", "InstanceId": "i-1"}, &target)
+	if err == nil || isDryRunError(err) {
+		t.Fatalf("performed action should be an error but not a dry run error: %v", err)
+	}
+	if exitCode(err) == 0 {
+		t.Error("exit code of performed action should not be 0")
+	}
+	entries, err := journal.Read(file, journal.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != "StopInstance" || entries[0].Error != "" {
+		t.Errorf("performed action should be recorded: %+v", entries)
+	}
+}
+
+func TestDryRunUnsupported(t *testing.T) {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	err = (&ECS{}).dryRun(context.Background(), map[string]string{"Action": "ModifyInstanceAttribute", "Password": "p4ss"}, nil)
+	os.Stdout = stdout
+	w.Close()
+	if !isDryRunError(err) {
+		t.Errorf("error should be dry run error instead of %v", err)
+	}
+	if output, _ := ioutil.ReadAll(r); len(output) > 0 {
+		t.Errorf("nothing should be printed to stdout instead of %q", output)
+	}
+}
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
 
+	"github.com/caiguanhao/aliyun/trace"
 	"github.com/codegangsta/cli"
 )
 
 type ECS struct {
 	KEY    string
 	SECRET string
+
+	ctx context.Context
+}
+
+// Context of requests, which is cancelled on interrupt.
+func (ecs *ECS) Context() context.Context {
+	if ecs.ctx == nil {
+		return context.Background()
+	}
+	return ecs.ctx
 }
 
 var ECS_INSTANCE ECS = ECS{KEY: KEY, SECRET: SECRET}
@@ -32,15 +44,29 @@ func main() {
 		DESCRIBE_INSTANCE_TYPES,
 		DESCRIBE_SECURITY_GROUPS,
 		CREATE_INSTANCE,
+		CLONE_INSTANCE,
 		ALLOCATE_PUBLIC_IP_ADDRESS,
 		START_INSTANCE,
 		STOP_INSTANCE,
 		RESTART_INSTANCE,
 		REMOVE_INSTANCE,
 		UPDATE_INSTANCE,
+		RESIZE_INSTANCE,
+		RESET_PASSWORD,
+		REINSTALL_INSTANCE,
 		HIDE_INSTANCE,
 		UNHIDE_INSTANCE,
 		DESCRIBE_INSTANCE_MONITOR_DATA,
+		DESCRIBE_DISK_MONITOR_DATA,
+		EXPORTER,
+		CHECK,
+		REPORT,
+		SSH,
+		SSH_CONFIG,
+		HOSTS,
+		INVENTORY,
+		CALL,
+		HISTORY,
 	}
 	app.Flags = []cli.Flag{
 		cli.BoolFlag{
@@ -53,6 +79,76 @@ func main() {
 			Usage:       "show more info",
 			Destination: &IsVerbose,
 		},
+		cli.BoolFlag{
+			Name:  "debug",
+			Usage: "log requests and responses with secrets masked",
+		},
+		cli.StringFlag{
+			Name:  "log-file",
+			Usage: "write --debug log to file instead of stderr, implies --debug",
+		},
+		cli.BoolFlag{
+			Name:        "dry-run, D",
+			Usage:       "validate or print requests of actions that change instances and others instead of running them",
+			Destination: &IsDryRun,
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Value: "text",
+			Usage: "text or json, json prints results and errors as JSON objects",
+		},
+		cli.StringFlag{
+			Name:   "profile",
+			Value:  DEFAULT_PROFILE,
+			Usage:  "use defaults of profile in ~/.aliyun/ecs.json or $ECS_CONFIG",
+			EnvVar: "ECS_PROFILE",
+		},
+		cli.StringSliceFlag{
+			Name:   "region",
+			Usage:  "only query instances and others in these regions, overrides profile",
+			EnvVar: "ECS_REGION",
+		},
+		cli.StringFlag{
+			Name:   "signature-version",
+			Usage:  "v1 (HMAC-SHA1, default) or v3 (ACS3-HMAC-SHA256), overrides profile",
+			EnvVar: "ECS_SIGNATURE_VERSION",
+		},
+	}
+	app.Before = func(c *cli.Context) (err error) {
+		ECS_INSTANCE.ctx = interruptContext()
+		if c.Bool("debug") || c.String("log-file") != "" {
+			if err = trace.Enable(c.String("log-file")); err != nil {
+				return
+			}
+		}
+		switch c.String("output") {
+		case "text":
+		case "json":
+			IsJSONOutput = true
+		default:
+			return fmt.Errorf("Invalid --output: %s", c.String("output"))
+		}
+		ProfileName = c.String("profile")
+		PROFILE, err = loadProfile(profileFile(), ProfileName)
+		if err != nil {
+			return
+		}
+		Regions = splitCommas(c.StringSlice("region"))
+		if len(Regions) == 0 {
+			Regions = PROFILE.Regions
+		}
+		SignatureVersion = c.String("signature-version")
+		if SignatureVersion == "" {
+			SignatureVersion = PROFILE.SignatureVersion
+		}
+		switch SignatureVersion {
+		case "":
+			SignatureVersion = SIGNATURE_V1
+		case SIGNATURE_V1, SIGNATURE_V3:
+		default:
+			return fmt.Errorf("Invalid signature version: %s", SignatureVersion)
+		}
+		return
 	}
 	app.BashComplete = func(c *cli.Context) {
 		for _, command := range c.App.Commands {
@@ -64,5 +160,8 @@ func main() {
 			}
 		}
 	}
-	app.Run(os.Args)
+	// errors of app.Before (like invalid --output) are printed with help
+	if err := app.Run(os.Args); err != nil {
+		os.Exit(exitCode(err))
+	}
 }
@@ -1,16 +1,195 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/caiguanhao/aliyun/ecs/errors"
+)
+
+// Exit codes of errors of each category.
+const (
+	EXIT_ERROR             = 1
+	EXIT_AUTH              = 3
+	EXIT_THROTTLING        = 4
+	EXIT_NOT_FOUND         = 5
+	EXIT_INVALID_PARAMETER = 6
+	EXIT_QUOTA             = 7
+	EXIT_NETWORK           = 8
 )
 
+var errorCategoryExitCodes = map[string]int{
+	"auth":              EXIT_AUTH,
+	"throttling":        EXIT_THROTTLING,
+	"not_found":         EXIT_NOT_FOUND,
+	"invalid_parameter": EXIT_INVALID_PARAMETER,
+	"quota":             EXIT_QUOTA,
+	"network":           EXIT_NETWORK,
+	"interrupted":       EXIT_INTERRUPTED,
+	"dry_run":           0,
+}
+
+// Print errors as JSON objects instead of text.
+var IsJSONOutput bool
+
+// ECSResponseError is an error returned by the API, or an error of the
+// request (with empty Code) like network failure. Region, InstanceId and
+// Action are taken from the request.
 type ECSResponseError struct {
-	Code      string `json:"Code"`
-	HostID    string `json:"HostId"`
-	Message   string `json:"Message"`
-	RequestID string `json:"RequestId"`
+	Code       string `json:"Code"`
+	HostID     string `json:"HostId"`
+	Message    string `json:"Message"`
+	RequestID  string `json:"RequestId"`
+	Region     string `json:"Region,omitempty"`
+	InstanceId string `json:"InstanceId,omitempty"`
+	Action     string `json:"Action,omitempty"`
+
+	cause error
 }
 
 func (err *ECSResponseError) Error() string {
-	return fmt.Sprintf("[FATAL] %s: %s", err.Code, err.Message)
+	var message string
+	if err.Code == "" {
+		message = "[FATAL] " + err.Message
+	} else {
+		message = fmt.Sprintf("[FATAL] %s: %s", err.Code, err.Message)
+	}
+	var context []string
+	for _, item := range [][2]string{
+		{"Region", err.Region},
+		{"Instance", err.InstanceId},
+		{"Action", err.Action},
+		{"RequestId", err.RequestID},
+	} {
+		if item[1] != "" {
+			context = append(context, item[0]+": "+item[1])
+		}
+	}
+	if len(context) > 0 {
+		message += " (" + strings.Join(context, ", ") + ")"
+	}
+	return message
+}
+
+// Category of error: auth, throttling, not_found, invalid_parameter, quota,
+// network, interrupted, dry_run or empty if unknown.
+func (err *ECSResponseError) Category() string {
+	if err.cause != nil {
+		return errorCategory(err.cause)
+	}
+	code := err.Code
+	switch {
+	case code == DRY_RUN_CODE:
+		return "dry_run"
+	case strings.HasPrefix(code, "InvalidAccessKey"),
+		strings.HasPrefix(code, "SignatureDoesNotMatch"),
+		strings.HasPrefix(code, "IncompleteSignature"),
+		strings.HasPrefix(code, "SignatureNonceUsed"),
+		strings.HasPrefix(code, "Forbidden"),
+		strings.HasPrefix(code, "InvalidTimeStamp"):
+		return "auth"
+	case strings.HasPrefix(code, "Throttling"),
+		code == "ServiceUnavailable":
+		return "throttling"
+	case strings.HasSuffix(code, "NotFound"),
+		strings.HasSuffix(code, ".NotExist"):
+		return "not_found"
+	case strings.Contains(code, "QuotaExceed"),
+		strings.HasPrefix(code, "Account.Arrearage"):
+		return "quota"
+	case strings.HasPrefix(code, "Invalid"),
+		strings.HasPrefix(code, "MissingParameter"),
+		strings.HasSuffix(code, ".Malformed"):
+		return "invalid_parameter"
+	}
+	return ""
+}
+
+// Add request context to err, wrapping errors that are not from the API.
+func withRequestContext(err error, queries map[string]string) error {
+	if err == nil {
+		return nil
+	}
+	ecsErr, ok := err.(*ECSResponseError)
+	if !ok {
+		ecsErr = &ECSResponseError{Message: err.Error(), cause: err}
+	}
+	if ecsErr.Region == "" {
+		ecsErr.Region = queries["RegionId"]
+	}
+	if ecsErr.InstanceId == "" {
+		ecsErr.InstanceId = queries["InstanceId"]
+	}
+	if ecsErr.Action == "" {
+		ecsErr.Action = queries["Action"]
+	}
+	return ecsErr
+}
+
+func errorCategory(err error) string {
+	if isInterrupted(err) || ECS_INSTANCE.Context().Err() != nil {
+		return "interrupted"
+	}
+	switch e := err.(type) {
+	case *ECSResponseError:
+		return e.Category()
+	case errors.Errors:
+		if len(e.Errors) > 0 {
+			return errorCategory(e.Errors[0])
+		}
+	case *url.Error:
+		return "network"
+	case net.Error:
+		return "network"
+	}
+	return ""
+}
+
+// Exit code of err by its category, or of the first error of errors.
+func exitCode(err error) int {
+	if code, ok := errorCategoryExitCodes[errorCategory(err)]; ok {
+		return code
+	}
+	return EXIT_ERROR
+}
+
+// Print err to stderr, as JSON objects (one per line) if IsJSONOutput.
+func printError(err error, level string) {
+	if !IsJSONOutput {
+		if level != "error" {
+			fmt.Fprintln(os.Stderr, strings.Replace(err.Error(), "[FATAL]", "["+strings.ToUpper(level)+"]", -1))
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return
+	}
+	if errs, ok := err.(errors.Errors); ok {
+		for _, err := range errs.Errors {
+			printError(err, level)
+		}
+		return
+	}
+	json.NewEncoder(os.Stderr).Encode(newErrorObject(err, level))
+}
+
+// JSON object of error with its level, category and exit code.
+type errorObject struct {
+	*ECSResponseError
+	Level    string `json:"Level"`
+	Category string `json:"Category,omitempty"`
+	ExitCode int    `json:"ExitCode"`
+}
+
+func newErrorObject(err error, level string) errorObject {
+	object := errorObject{Level: level, Category: errorCategory(err), ExitCode: exitCode(err)}
+	if ecsErr, ok := err.(*ECSResponseError); ok {
+		object.ECSResponseError = ecsErr
+	} else {
+		object.ECSResponseError = &ECSResponseError{Message: err.Error()}
+	}
+	return object
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	ecsErrors "github.com/caiguanhao/aliyun/ecs/errors"
+)
+
+func testExitCode(t *testing.T, err error, expected int) {
+	if actual := exitCode(err); actual != expected {
+		t.Errorf("exit code of %q should be %d instead of %d", err, expected, actual)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testExitCode(t, &ECSResponseError{Code: "InvalidAccessKeyId.NotFound"}, EXIT_AUTH)
+	testExitCode(t, &ECSResponseError{Code: "SignatureDoesNotMatch"}, EXIT_AUTH)
+	testExitCode(t, &ECSResponseError{Code: "Throttling.User"}, EXIT_THROTTLING)
+	testExitCode(t, &ECSResponseError{Code: "InvalidInstanceId.NotFound"}, EXIT_NOT_FOUND)
+	testExitCode(t, &ECSResponseError{Code: "InvalidParameter"}, EXIT_INVALID_PARAMETER)
+	testExitCode(t, &ECSResponseError{Code: "MissingParameter"}, EXIT_INVALID_PARAMETER)
+	testExitCode(t, &ECSResponseError{Code: "QuotaExceed.Instance"}, EXIT_QUOTA)
+	testExitCode(t, &ECSResponseError{Code: "InternalError"}, EXIT_ERROR)
+	testExitCode(t, errors.New("oops"), EXIT_ERROR)
+	network := withRequestContext(&url.Error{Op: "Get", URL: "http://ecs.aliyuncs.com/", Err: errors.New("timeout")}, nil)
+	testExitCode(t, network, EXIT_NETWORK)
+	interrupted := withRequestContext(&url.Error{Op: "Get", URL: "http://ecs.aliyuncs.com/", Err: context.Canceled}, nil)
+	testExitCode(t, interrupted, EXIT_INTERRUPTED)
+	var errs ecsErrors.Errors
+	errs.Add(&ECSResponseError{Code: "Throttling"})
+	errs.Add(errors.New("oops"))
+	testExitCode(t, errs.Errorify(), EXIT_THROTTLING)
+}
+
+func TestWithRequestContext(t *testing.T) {
+	err := withRequestContext(&ECSResponseError{
+		Code:      "IncorrectInstanceStatus",
+		Message:   "The current status of the resource does not support this operation.",
+		RequestID: "6EF60BEC-0242-43AF-BB20-270359FB54A7",
+	}, map[string]string{
+		"Action":     "StartInstance",
+		"RegionId":   "cn-hangzhou",
+		"InstanceId": "i-1",
+	})
+	expected := "[FATAL] IncorrectInstanceStatus: The current status of the resource does not support this operation. " +
+		"(Region: cn-hangzhou, Instance: i-1, Action: StartInstance, RequestId: 6EF60BEC-0242-43AF-BB20-270359FB54A7)"
+	if err.Error() != expected {
+		t.Errorf("error should be %q instead of %q", expected, err.Error())
+	}
+	if withRequestContext(nil, nil) != nil {
+		t.Error("withRequestContext(nil) should be nil")
+	}
+}
+
+func TestDryRunError(t *testing.T) {
+	err := withRequestContext(&ECSResponseError{Code: DRY_RUN_CODE, Message: "Request is valid."}, nil)
+	if !isDryRunError(err) {
+		t.Errorf("%q should be a dry run error", err)
+	}
+	testExitCode(t, err, 0)
+	if isDryRunError(&ECSResponseError{Code: "InvalidParameter"}) {
+		t.Error("InvalidParameter should not be a dry run error")
+	}
+}
@@ -1,15 +1,16 @@
 package errors
 
 import (
-	"errors"
 	"strings"
 )
 
+// Errors collects errors and keeps the original ones so that they can still
+// be inspected after being combined into one error.
 type Errors struct {
-	Errors []string
+	Errors []error
 }
 
-func (errs *Errors) Add(err string) {
+func (errs *Errors) Add(err error) {
 	(*errs).Errors = append((*errs).Errors, err)
 }
 
@@ -17,6 +18,18 @@ func (errs Errors) HaveError() bool {
 	return len(errs.Errors) > 0
 }
 
+// Returns the only error if there is one, otherwise errs itself.
 func (errs Errors) Errorify() error {
-	return errors.New(strings.Join(errs.Errors, "\n"))
+	if len(errs.Errors) == 1 {
+		return errs.Errors[0]
+	}
+	return errs
+}
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs.Errors))
+	for i, err := range errs.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
 }
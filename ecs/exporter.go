@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const EXPORTER_NAMESPACE = "aliyun_ecs"
+
+var EXPORTER cli.Command = cli.Command{
+	Name:      "exporter",
+	Aliases:   []string{"export"},
+	Usage:     "serve metrics of all instances for Prometheus",
+	ArgsUsage: " ",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen, l",
+			Value: ":9157",
+			Usage: "address to listen on",
+		},
+		cli.IntFlag{
+			Name:  "interval, i",
+			Value: 60,
+			Usage: "collect metrics every N seconds",
+		},
+		cli.IntFlag{
+			Name:  "concurrency, c",
+			Value: 4,
+			Usage: "max number of instances to collect metrics of at the same time",
+		},
+	},
+	Action: func(c *cli.Context) {
+		exporter := &Exporter{ecs: &ECS_INSTANCE, concurrency: c.Int("concurrency")}
+		if exporter.concurrency < 1 {
+			exporter.concurrency = 1
+		}
+		interval := time.Duration(c.Int("interval")) * time.Second
+		if interval < time.Minute {
+			interval = time.Minute
+		}
+		go func() {
+			for {
+				exporter.Collect()
+				time.Sleep(interval)
+			}
+		}()
+		http.Handle("/metrics", exporter)
+		fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics\n", c.String("listen"))
+		go func() {
+			<-ECS_INSTANCE.Context().Done()
+			os.Exit(EXIT_INTERRUPTED)
+		}()
+		exit(http.ListenAndServe(c.String("listen"), nil))
+	},
+}
+
+type Exporter struct {
+	ecs         *ECS
+	concurrency int
+
+	mutex     sync.Mutex
+	metrics   []byte
+	apiErrors map[string]int
+}
+
+type promSample struct {
+	labels map[string]string
+	value  float64
+}
+
+type promMetric struct {
+	name string
+	help string
+	// type of metric like counter, defaults to gauge
+	typ     string
+	samples []promSample
+}
+
+// Name of the metric in Prometheus like aliyun_ecs_net_in_bytes_per_second.
+func (metric instanceMetric) PromName() string {
+	name := EXPORTER_NAMESPACE + "_" + strings.Replace(metric.Name, "-", "_", -1)
+	switch metric.Unit {
+	case "%":
+		return name + "_percent"
+	case "B/s":
+		return name + "_bytes_per_second"
+	}
+	return name
+}
+
+func promEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func promLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, key, promEscape(labels[key]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func writePromMetrics(buf *bytes.Buffer, metrics []promMetric) {
+	for _, metric := range metrics {
+		fmt.Fprintf(buf, "# HELP %s %s\n", metric.name, metric.help)
+		typ := metric.typ
+		if typ == "" {
+			typ = "gauge"
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", metric.name, typ)
+		for _, sample := range metric.samples {
+			fmt.Fprintf(buf, "%s%s %g\n", metric.name, promLabels(sample.labels), sample.value)
+		}
+	}
+}
+
+func instanceLabels(instance ECSInstance) map[string]string {
+	return map[string]string{
+		"id":     instance.InstanceId,
+		"name":   instance.InstanceName,
+		"region": instance.RegionId,
+		"zone":   instance.ZoneId,
+		"type":   instance.InstanceType,
+	}
+}
+
+func (exporter *Exporter) addAPIError(action string) {
+	exporter.mutex.Lock()
+	if exporter.apiErrors == nil {
+		exporter.apiErrors = map[string]int{}
+	}
+	exporter.apiErrors[action]++
+	exporter.mutex.Unlock()
+}
+
+// Get latest stats of all running instances and replace metrics to serve.
+func (exporter *Exporter) Collect() {
+	start := time.Now()
+	success := 1.0
+	instances, err := exporter.ecs.DescribeInstances()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exporter.addAPIError("DescribeInstances")
+		success = 0
+	}
+
+	statusMetric := promMetric{name: EXPORTER_NAMESPACE + "_instance_status", help: "Status of instance, value is always 1"}
+	metrics := make([]promMetric, len(INSTANCE_METRICS))
+	for i, metric := range INSTANCE_METRICS {
+		metrics[i] = promMetric{name: metric.PromName(), help: metric.Title}
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan bool, exporter.concurrency)
+	end := time.Now().UTC()
+	for _, instance := range instances {
+		labels := instanceLabels(instance)
+		statusLabels := instanceLabels(instance)
+		statusLabels["status"] = instance.Status
+		statusMetric.samples = append(statusMetric.samples, promSample{statusLabels, 1})
+		if instance.Status != "Running" {
+			continue
+		}
+		wg.Add(1)
+		go func(instance ECSInstance) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+			data, _, err := exporter.ecs.DescribeInstanceMonitorData(instance.InstanceId, end.Add(-10*time.Minute), end, 60)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, instance.InstanceId+":", err)
+				exporter.addAPIError("DescribeInstanceMonitorData")
+				return
+			}
+			if len(data) == 0 {
+				return
+			}
+			latest := data[len(data)-1]
+			mutex.Lock()
+			for i, metric := range INSTANCE_METRICS {
+				metrics[i].samples = append(metrics[i].samples, promSample{labels, metric.Value(latest, 60)})
+			}
+			mutex.Unlock()
+		}(instance)
+	}
+	wg.Wait()
+
+	for i := range metrics {
+		sort.Sort(promSamples(metrics[i].samples))
+	}
+	metrics = append([]promMetric{statusMetric}, metrics...)
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	errorsMetric := promMetric{name: EXPORTER_NAMESPACE + "_api_errors_total", help: "Number of failed API requests since start", typ: "counter"}
+	for action, count := range exporter.apiErrors {
+		errorsMetric.samples = append(errorsMetric.samples, promSample{map[string]string{"action": action}, float64(count)})
+	}
+	sort.Sort(promSamples(errorsMetric.samples))
+	metrics = append(metrics, errorsMetric,
+		promMetric{name: EXPORTER_NAMESPACE + "_collect_success", help: "Whether instances were listed successfully in last collection", samples: []promSample{{nil, success}}},
+		promMetric{name: EXPORTER_NAMESPACE + "_collect_duration_seconds", help: "Time used in last collection", samples: []promSample{{nil, time.Since(start).Seconds()}}},
+		promMetric{name: EXPORTER_NAMESPACE + "_collect_timestamp_seconds", help: "Time of last collection", samples: []promSample{{nil, float64(start.Unix())}}},
+	)
+	var buf bytes.Buffer
+	writePromMetrics(&buf, metrics)
+	exporter.metrics = buf.Bytes()
+}
+
+func (exporter *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	exporter.mutex.Lock()
+	metrics := exporter.metrics
+	exporter.mutex.Unlock()
+	if metrics == nil {
+		http.Error(w, "Metrics are not collected yet.", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(metrics)
+}
+
+type promSamples []promSample
+
+func (a promSamples) Len() int           { return len(a) }
+func (a promSamples) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a promSamples) Less(i, j int) bool { return promLabels(a[i].labels) < promLabels(a[j].labels) }
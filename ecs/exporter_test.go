@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPromLabels(t *testing.T) {
+	labels := promLabels(map[string]string{"name": `web "01"`, "id": "i-1"})
+	if expected := `{id="i-1",name="web \"01\""}`; labels != expected {
+		t.Errorf("%s should be %s", labels, expected)
+	}
+	if labels := promLabels(nil); labels != "" {
+		t.Errorf("%s should be empty", labels)
+	}
+}
+
+func TestPromName(t *testing.T) {
+	for _, metric := range INSTANCE_METRICS {
+		if metric.Name == "net-in" && metric.PromName() != "aliyun_ecs_net_in_bytes_per_second" {
+			t.Errorf("%s is wrong name for net-in", metric.PromName())
+		}
+		if metric.Name == "cpu" && metric.PromName() != "aliyun_ecs_cpu_percent" {
+			t.Errorf("%s is wrong name for cpu", metric.PromName())
+		}
+	}
+}
+
+func TestWritePromMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writePromMetrics(&buf, []promMetric{
+		{name: "aliyun_ecs_cpu_percent", help: "CPU Usage", samples: []promSample{{map[string]string{"id": "i-1"}, 13}}},
+		{name: "aliyun_ecs_api_errors_total", help: "API Errors", typ: "counter", samples: []promSample{{map[string]string{"action": "Foo"}, 2}}},
+	})
+	expected := "# HELP aliyun_ecs_cpu_percent CPU Usage\n" +
+		"# TYPE aliyun_ecs_cpu_percent gauge\n" +
+		"aliyun_ecs_cpu_percent{id=\"i-1\"} 13\n" +
+		"# HELP aliyun_ecs_api_errors_total API Errors\n" +
+		"# TYPE aliyun_ecs_api_errors_total counter\n" +
+		"aliyun_ecs_api_errors_total{action=\"Foo\"} 2\n"
+	if buf.String() != expected {
+		t.Errorf("%q should be %q", buf.String(), expected)
+	}
+}
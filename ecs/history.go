@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/caiguanhao/aliyun/journal"
+	"github.com/codegangsta/cli"
+)
+
+var HISTORY cli.Command = cli.Command{
+	Name:      "history",
+	Usage:     "show journal of operations that changed instances and others",
+	ArgsUsage: " ",
+	Description: `Every API action other than Describe* is recorded in ~/.aliyun/journal.jsonl,
+   or the file in ALIYUN_JOURNAL environment variable (set to "off" to disable).
+   --since and --until can be time like 2006-01-02 15:04 or duration like 24h.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "since, s",
+			Usage: "show operations since the time",
+		},
+		cli.StringFlag{
+			Name:  "until, u",
+			Usage: "show operations until the time",
+		},
+		cli.StringFlag{
+			Name:  "action, a",
+			Usage: "show operations of actions containing the text, like stop",
+		},
+		cli.StringFlag{
+			Name:  "target, t",
+			Usage: "show operations on targets containing the text, like instance ID",
+		},
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "also show operations of the oss tool",
+		},
+	},
+	Action: func(c *cli.Context) {
+		filter := journal.Filter{
+			Tool:   "ecs",
+			Action: c.String("action"),
+			Target: c.String("target"),
+		}
+		if c.Bool("all") {
+			filter.Tool = ""
+		}
+		var err error
+		if c.IsSet("since") {
+			if filter.Since, err = journal.ParseTime(c.String("since")); err != nil {
+				exit(err)
+			}
+		}
+		if c.IsSet("until") {
+			if filter.Until, err = journal.ParseTime(c.String("until")); err != nil {
+				exit(err)
+			}
+		}
+		entries, err := journal.Read(journal.File(), filter)
+		Print(JournalEntries(entries), err)
+	},
+}
+
+// Read-only actions are not recorded.
+func isMutatingAction(action string) bool {
+	for _, prefix := range []string{"Describe", "List", "Query", "Get", "Check"} {
+		if strings.HasPrefix(action, prefix) {
+			return false
+		}
+	}
+	return action != ""
+}
+
+// Keys of request parameters and response fields that are IDs of resources.
+var journalTargetKeys = []string{"InstanceId", "InstanceIds", "DiskId", "ImageId", "SnapshotId", "SecurityGroupId", "AllocationId"}
+
+func recordRequest(queries map[string]string, target interface{}, err error) {
+	entry := journal.Entry{
+		Tool:    "ecs",
+		Profile: ProfileName,
+		Action:  queries["Action"],
+		Params:  map[string]string{},
+	}
+	for key, value := range queries {
+		if key != "Action" {
+			entry.Params[key] = value
+		}
+	}
+	for _, key := range journalTargetKeys {
+		if value := queries[key]; value != "" {
+			entry.Targets = append(entry.Targets, value)
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		if ecsErr, ok := err.(*ECSResponseError); ok {
+			entry.RequestId = ecsErr.RequestID
+		}
+	} else {
+		// IDs of created resources and RequestId are in the response
+		var response map[string]interface{}
+		if data, err := json.Marshal(target); err == nil && json.Unmarshal(data, &response) == nil {
+			for _, key := range journalTargetKeys {
+				if value, ok := response[key].(string); ok && value != "" && queries[key] == "" {
+					entry.Targets = append(entry.Targets, value)
+				}
+			}
+			entry.RequestId, _ = response["RequestId"].(string)
+		}
+	}
+	journal.RecordOrWarn(entry)
+}
+
+type JournalEntries journal.Entries
+
+func (entries JournalEntries) Print() {
+	for _, entry := range entries {
+		fields := []string{entry.Time.Format(time.RFC3339), entry.User, entry.Action, entry.Result}
+		fields = append(fields, entry.Targets...)
+		fmt.Println(strings.Join(fields, "\t"))
+	}
+}
+
+func (entries JournalEntries) PrintTable() {
+	fields := []interface{}{"Time", "User", "Profile", "Action", "Targets", "Result", "Request ID"}
+	PrintTable(
+		/* fields     */ fields,
+		/* showFields */ true,
+		/* listLength */ len(entries),
+		/* filter     */ nil,
+		/* getInfo    */ func(i int) map[interface{}]interface{} {
+			entry := entries[i]
+			result := entry.Result
+			if entry.Error != "" {
+				result += ": " + entry.Error
+			}
+			return map[interface{}]interface{}{
+				"Time":       entry.Time.Local().Format(YMD_HMS_FORMAT),
+				"User":       entry.User,
+				"Profile":    entry.Profile,
+				"Action":     entry.Action,
+				"Targets":    strings.Join(entry.Targets, ", "),
+				"Result":     result,
+				"Request ID": entry.RequestId,
+			}
+		},
+	)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/codegangsta/cli"
+)
+
+var HOSTS cli.Command = cli.Command{
+	Name:      "hosts",
+	Usage:     "print or write host names and IP addresses of instances for /etc/hosts",
+	ArgsUsage: " ",
+	Description: `Entries are put between "# BEGIN aliyun-ecs" and "# END aliyun-ecs" lines,
+   which are replaced on each --write. Original file is saved with .bak suffix.
+   With --ip public,private, names of private IP addresses have --private-suffix.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "write, w",
+			Usage: "write entries to --file instead of printing them",
+		},
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "exit with status 1 if --file is out of date",
+		},
+		cli.StringFlag{
+			Name:  "file",
+			Value: "/etc/hosts",
+			Usage: "hosts file to write or check",
+		},
+		cli.StringFlag{
+			Name:  "ip",
+			Value: "public",
+			Usage: "public, private or public,private IP addresses",
+		},
+		cli.StringFlag{
+			Name:  "prefix",
+			Usage: "add prefix to host names",
+		},
+		cli.StringFlag{
+			Name:  "suffix",
+			Usage: "add suffix to host names, like .example.com",
+		},
+		cli.StringFlag{
+			Name:  "private-suffix",
+			Value: "-private",
+			Usage: "add suffix to host names of private IP addresses when --ip public,private",
+		},
+	},
+	Action: func(c *cli.Context) {
+		var public, private bool
+		for _, ip := range splitCommas([]string{c.String("ip")}) {
+			switch ip {
+			case "public":
+				public = true
+			case "private":
+				private = true
+			default:
+				exit("Invalid --ip:", ip)
+			}
+		}
+		privateSuffix := ""
+		if public && private {
+			privateSuffix = c.String("private-suffix")
+		}
+		instances, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			exit(err)
+		}
+		var hosts bytes.Buffer
+		for _, instance := range instances {
+			if !shouldShow(instance) || instance.InstanceName == "" {
+				continue
+			}
+			name := c.String("prefix") + toHostName(instance.InstanceName)
+			if ip := instance.PublicIP(); public && ip != "" {
+				fmt.Fprintf(&hosts, "%s %s%s\n", ip, name, c.String("suffix"))
+			}
+			if ip := instance.PrivateIP(); private && ip != "" {
+				fmt.Fprintf(&hosts, "%s %s%s%s\n", ip, name, privateSuffix, c.String("suffix"))
+			}
+		}
+		file := c.String("file")
+		if c.Bool("check") {
+			content, updated, err := readManagedBlock(file, hosts.String(), nil)
+			if err != nil {
+				exit(err)
+			}
+			if content != updated {
+				fmt.Fprintln(os.Stderr, file, "is out of date")
+				os.Exit(1)
+			}
+			fmt.Println(file, "is up to date")
+			return
+		}
+		if !c.Bool("write") {
+			fmt.Print(hosts.String())
+			return
+		}
+		changed, err := writeManagedBlock(file, hosts.String(), nil, true)
+		if err != nil {
+			exit(err)
+		}
+		if changed {
+			fmt.Println("Updated", file)
+		} else {
+			fmt.Println(file, "is up to date")
+		}
+	},
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/codegangsta/cli"
 )
@@ -93,6 +96,73 @@ func (ecs *ECS) StopInstanceById(id string) (ActionResponse, error) {
 	return executeInstanceActionById(ecs, "StopInstance", id)
 }
 
+func (ecs *ECS) WaitForInstanceStatusById(id, status string) error {
+	return waitFor(ecs.Context(), fmt.Sprintf("instance %s to be %s", id, status), 10*time.Minute, func() (bool, error) {
+		instance, err := ecs.DescribeInstanceAttributeById(id)
+		return instance.Status == status, err
+	})
+}
+
+// Stop the instance if it is running, call do and then start it again. Once
+// stopped, the instance is started again even if interrupted, or the error
+// says it is left stopped.
+func (ecs *ECS) DoWhileInstanceStopped(instance ECSInstance, do func() error) (err error) {
+	id := instance.InstanceId
+	if instance.Status != "Running" {
+		return do()
+	}
+	if _, err = ecs.StopInstanceById(id); err != nil {
+		return
+	}
+	stopped := false
+	defer func() {
+		if !stopped && !isInterrupted(err) {
+			return
+		}
+		if serr := ecs.startStoppedInstance(id, stopped); serr != nil {
+			serr = fmt.Errorf("Instance %s is left stopped: %s", id, serr)
+			if err == nil {
+				err = serr
+			} else {
+				printError(serr, "error")
+			}
+		}
+	}()
+	if err = ecs.WaitForInstanceStatusById(id, "Stopped"); err != nil {
+		return
+	}
+	stopped = true
+	err = do()
+	return
+}
+
+// Start instance (after it is stopped) and wait until it is running. Requests
+// are not cancelled on interrupt so that the instance is not left stopped.
+func (ecs *ECS) startStoppedInstance(id string, stopped bool) error {
+	background := *ecs
+	background.ctx = context.Background()
+	if ecs.Context().Err() != nil {
+		fmt.Fprintf(os.Stderr, "Starting instance %s again before quitting ...\n", id)
+	}
+	if !stopped {
+		if err := background.WaitForInstanceStatusById(id, "Stopped"); err != nil {
+			return err
+		}
+	}
+	if _, err := background.StartInstanceById(id); err != nil {
+		return err
+	}
+	return background.WaitForInstanceStatusById(id, "Running")
+}
+
+func (ecs *ECS) JoinSecurityGroupById(id, group string) (resp ActionResponse, _ error) {
+	return resp, ecs.Request(map[string]string{
+		"Action":          "JoinSecurityGroup",
+		"InstanceId":      id,
+		"SecurityGroupId": group,
+	}, &resp)
+}
+
 func (resp ActionResponse) Print() {
 	fmt.Println(resp.RequestId)
 }
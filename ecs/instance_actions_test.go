@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDoWhileInstanceStoppedInterrupted(t *testing.T) {
+	os.Setenv("ALIYUN_JOURNAL", "off")
+	defer os.Unsetenv("ALIYUN_JOURNAL")
+	var mutex sync.Mutex
+	status := "Running"
+	var actions []string
+	oldTransport := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		action := req.URL.Query().Get("Action")
+		actions = append(actions, action)
+		switch action {
+		case "StopInstance":
+			status = "Stopped"
+		case "StartInstance":
+			status = "Running"
+		}
+		body := fmt.Sprintf(`{"RequestId":"request-1","InstanceId":"i-1","Status":"%s"}`, status)
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+	defer func() {
+		http.DefaultTransport = oldTransport
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ecs := &ECS{ctx: ctx}
+	err := ecs.DoWhileInstanceStopped(ECSInstance{InstanceId: "i-1", Status: "Running"}, func() error {
+		cancel()
+		return context.Canceled
+	})
+	if !isInterrupted(err) {
+		t.Errorf("error should be interrupted instead of %v", err)
+	}
+	if status != "Running" {
+		t.Errorf("instance should be started again after interrupted: %v", actions)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+)
+
+// Exit code when interrupted, 128 + SIGINT like shells.
+const EXIT_INTERRUPTED = 130
+
+// Returns a context that is cancelled on the first interrupt so that
+// running requests can stop cleanly. The second interrupt exits immediately.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping. Press CTRL-C again to quit now.")
+		cancel()
+		<-signals
+		os.Exit(EXIT_INTERRUPTED)
+	}()
+	return ctx
+}
+
+// Whether err is caused by cancellation of context.
+func isInterrupted(err error) bool {
+	for err != nil {
+		if err == context.Canceled {
+			return true
+		}
+		switch e := err.(type) {
+		case *ECSResponseError:
+			err = e.cause
+		case *url.Error:
+			err = e.Err
+		default:
+			return false
+		}
+	}
+	return false
+}
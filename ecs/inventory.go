@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/codegangsta/cli"
+)
+
+var INVENTORY cli.Command = cli.Command{
+	Name:      "inventory",
+	Usage:     "print instances as Ansible dynamic inventory",
+	ArgsUsage: " ",
+	Description: `Hosts are grouped by region, zone, type, status, security group and tag,
+   like region_cn_hangzhou or tag_env_production. Use it in a script like:
+
+       #!/bin/sh
+       exec ecs inventory "$@"`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "list",
+			Usage: "print all groups and hosts",
+		},
+		cli.StringFlag{
+			Name:  "host",
+			Usage: "print variables of host",
+		},
+		cli.BoolFlag{
+			Name:  "private",
+			Usage: "connect to private IP address",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if !c.Bool("list") && !c.IsSet("host") {
+			exit("Please specify --list or --host.")
+		}
+		instances, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			exit(err)
+		}
+		inventory := ansibleInventory(instances, c.Bool("private"))
+		var output interface{} = inventory
+		if c.IsSet("host") {
+			hostvars := inventory["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+			output = hostvars[c.String("host")]
+			if output == nil {
+				output = map[string]interface{}{}
+			}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		if err := encoder.Encode(output); err != nil {
+			exit(err)
+		}
+	},
+}
+
+var invalidGroupNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func ansibleGroupName(parts ...string) (name string) {
+	for i, part := range parts {
+		if i > 0 {
+			name += "_"
+		}
+		name += invalidGroupNameRegexp.ReplaceAllString(part, "_")
+	}
+	return
+}
+
+// Build inventory in the format of Ansible dynamic inventory. Hosts are
+// named after instances, or IDs if names are empty or duplicated.
+func ansibleInventory(instances ECSInstances, private bool) map[string]interface{} {
+	names := map[string]int{}
+	for _, instance := range instances {
+		names[toHostName(instance.InstanceName)]++
+	}
+	groups := map[string][]string{}
+	hostvars := map[string]interface{}{}
+	for _, instance := range instances {
+		if !shouldShow(instance) {
+			continue
+		}
+		host := toHostName(instance.InstanceName)
+		if host == "" || names[host] > 1 {
+			host = instance.InstanceId
+		}
+		addToGroup := func(parts ...string) {
+			group := ansibleGroupName(parts...)
+			groups[group] = append(groups[group], host)
+		}
+		addToGroup("region", instance.RegionId)
+		addToGroup("zone", instance.ZoneId)
+		addToGroup("type", instance.InstanceType)
+		addToGroup("status", instance.Status)
+		for _, group := range instance.SecurityGroupIds.SecurityGroupId {
+			addToGroup("security_group", group)
+		}
+		for _, tag := range instance.Tags.Tag {
+			addToGroup("tag", tag.TagKey, tag.TagValue)
+		}
+
+		ip := instance.PublicIP()
+		if private || ip == "" {
+			ip = instance.PrivateIP()
+		}
+		hostvars[host] = map[string]interface{}{
+			"ansible_host": ip,
+			"ecs":          instance,
+		}
+	}
+	inventory := map[string]interface{}{
+		"_meta": map[string]interface{}{
+			"hostvars": hostvars,
+		},
+	}
+	all := []string{}
+	for group, hosts := range groups {
+		sort.Strings(hosts)
+		inventory[group] = map[string]interface{}{
+			"hosts": hosts,
+		}
+		all = append(all, group)
+	}
+	sort.Strings(all)
+	inventory["all"] = map[string]interface{}{
+		"children": all,
+	}
+	return inventory
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnsibleInventory(t *testing.T) {
+	web := testInstance("i-1", "web", "1.1.1.1", "10.0.0.1")
+	web.RegionId, web.ZoneId, web.Status = "cn-hangzhou", "cn-hangzhou-b", "Running"
+	web.SecurityGroupIds.SecurityGroupId = []string{"sg-1"}
+	web.Tags.Tag = []ECSInstanceTag{{"env", "prod"}}
+	dup1 := testInstance("i-2", "db", "", "10.0.0.2")
+	dup2 := testInstance("i-3", "db", "", "10.0.0.3")
+	dup1.RegionId, dup2.RegionId = "cn-hangzhou", "cn-qingdao"
+
+	inventory := ansibleInventory(ECSInstances{web, dup1, dup2}, false)
+	groups := map[string][]string{
+		"region_cn_hangzhou":  {"i-2", "web"},
+		"region_cn_qingdao":   {"i-3"},
+		"zone_cn_hangzhou_b":  {"web"},
+		"status_Running":      {"web"},
+		"security_group_sg_1": {"web"},
+		"tag_env_prod":        {"web"},
+	}
+	for group, expected := range groups {
+		actual, _ := inventory[group].(map[string]interface{})["hosts"].([]string)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("hosts of %s should be %v instead of %v", group, expected, actual)
+		}
+	}
+	hostvars := inventory["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+	for host, expected := range map[string]string{"web": "1.1.1.1", "i-2": "10.0.0.2", "i-3": "10.0.0.3"} {
+		vars, _ := hostvars[host].(map[string]interface{})
+		if actual := vars["ansible_host"]; actual != expected {
+			t.Errorf("ansible_host of %s should be %s instead of %v", host, expected, actual)
+		}
+	}
+}
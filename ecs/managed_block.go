@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+const MANAGED_BLOCK_BEGIN = "# BEGIN aliyun-ecs"
+const MANAGED_BLOCK_END = "# END aliyun-ecs"
+
+// Replace lines between MANAGED_BLOCK_BEGIN and MANAGED_BLOCK_END (markers
+// included) in content with block wrapped in the markers. The block is
+// appended if content has no markers, or removed if block is empty. If
+// before is not nil, the block is moved to or inserted before the first line
+// matching it, like Host of ssh config where the first value found is used.
+func replaceManagedBlock(content, block string, before *regexp.Regexp) string {
+	if before != nil && block != "" {
+		rest := replaceManagedBlock(content, "", nil)
+		if loc := before.FindStringIndex(rest); loc != nil {
+			block = MANAGED_BLOCK_BEGIN + "\n" + strings.TrimRight(block, "\n") + "\n" + MANAGED_BLOCK_END + "\n"
+			return rest[:loc[0]] + block + rest[loc[0]:]
+		}
+	}
+	if block != "" {
+		block = MANAGED_BLOCK_BEGIN + "\n" + strings.TrimRight(block, "\n") + "\n" + MANAGED_BLOCK_END + "\n"
+	}
+	begin := strings.Index(content, MANAGED_BLOCK_BEGIN+"\n")
+	if begin > -1 && (begin == 0 || content[begin-1] == '\n') {
+		end := strings.Index(content[begin:], "\n"+MANAGED_BLOCK_END)
+		if end > -1 {
+			end += begin + len("\n"+MANAGED_BLOCK_END)
+			if end < len(content) && content[end] == '\n' {
+				end++
+			}
+			return content[:begin] + block + content[end:]
+		}
+	}
+	if block == "" {
+		return content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block
+}
+
+// Get current content of file and the content with block replaced. A file
+// that does not exist is treated as empty.
+func readManagedBlock(file, block string, before *regexp.Regexp) (content, updated string, err error) {
+	var data []byte
+	data, err = ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	content = string(data)
+	updated = replaceManagedBlock(content, block, before)
+	err = nil
+	return
+}
+
+// Put block into file, creating the file if it does not exist. Returns false
+// if the file already has the same block. With backup, original content of
+// the file is saved to file.bak first. If file is a symlink, its target is
+// written instead so that the link is kept. See replaceManagedBlock for before.
+func writeManagedBlock(file, block string, before *regexp.Regexp, backup bool) (changed bool, err error) {
+	if target, err := filepath.EvalSymlinks(file); err == nil {
+		file = target
+	}
+	var content, updated string
+	content, updated, err = readManagedBlock(file, block, before)
+	if err != nil || updated == content {
+		return
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		info, err = nil, nil
+	}
+	if info != nil && backup {
+		if err = writeFileAtomically(file+".bak", []byte(content), info); err != nil {
+			return
+		}
+	}
+	return true, writeFileAtomically(file, []byte(updated), info)
+}
+
+// Write data to a temporary file in the same directory and rename it to file,
+// so that readers never see a partially written file. The new file has mode
+// and owner of info, or mode 0644 if info is nil.
+func writeFileAtomically(file string, data []byte, info os.FileInfo) (err error) {
+	var tmp *os.File
+	tmp, err = ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	mode := os.FileMode(0644)
+	if info != nil {
+		mode = info.Mode()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			if err = os.Chown(tmp.Name(), int(stat.Uid), int(stat.Gid)); err != nil {
+				return
+			}
+		}
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), file)
+}
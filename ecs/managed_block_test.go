@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testReplaceManagedBlock(t *testing.T, content, block, expected string) {
+	actual := replaceManagedBlock(content, block, nil)
+	if actual != expected {
+		t.Errorf("replaceManagedBlock(%q, %q) should be %q instead of %q", content, block, expected, actual)
+	}
+	if again := replaceManagedBlock(actual, block, nil); again != actual {
+		t.Errorf("replaceManagedBlock(%q, %q) should be idempotent but got %q", actual, block, again)
+	}
+}
+
+func TestReplaceManagedBlock(t *testing.T) {
+	testReplaceManagedBlock(t, "", "a\n", "# BEGIN aliyun-ecs\na\n# END aliyun-ecs\n")
+	testReplaceManagedBlock(t, "x", "a", "x\n\n# BEGIN aliyun-ecs\na\n# END aliyun-ecs\n")
+	testReplaceManagedBlock(t, "x\n# BEGIN aliyun-ecs\nold\n# END aliyun-ecs\ny\n", "a\nb\n",
+		"x\n# BEGIN aliyun-ecs\na\nb\n# END aliyun-ecs\ny\n")
+	testReplaceManagedBlock(t, "x\n# BEGIN aliyun-ecs\nold\n# END aliyun-ecs\ny\n", "", "x\ny\n")
+	testReplaceManagedBlock(t, "x\n", "", "x\n")
+}
+
+func TestReplaceManagedBlockBefore(t *testing.T) {
+	hostAll := "User root\n\nHost *\n  User admin\n  IdentityFile ~/.ssh/other\n"
+	expected := "User root\n\n# BEGIN aliyun-ecs\nHost web\n  User ecs\n# END aliyun-ecs\nHost *\n  User admin\n  IdentityFile ~/.ssh/other\n"
+	actual := replaceManagedBlock(hostAll, "Host web\n  User ecs\n", SSH_CONFIG_SECTION)
+	if actual != expected {
+		t.Errorf("block should be put before Host * as %q instead of %q", expected, actual)
+	}
+	if again := replaceManagedBlock(actual, "Host web\n  User ecs\n", SSH_CONFIG_SECTION); again != actual {
+		t.Errorf("replaceManagedBlock should be idempotent but got %q", again)
+	}
+	// block written after Host * before is moved
+	appended := replaceManagedBlock(hostAll, "Host web\n", nil)
+	if actual := replaceManagedBlock(appended, "Host web\n  User ecs\n", SSH_CONFIG_SECTION); !strings.HasPrefix(actual, "User root\n\n# BEGIN aliyun-ecs\n") {
+		t.Errorf("block should be moved before Host * instead of %q", actual)
+	}
+	if actual := replaceManagedBlock("User root\n", "Host web\n", SSH_CONFIG_SECTION); actual != "User root\n\n# BEGIN aliyun-ecs\nHost web\n# END aliyun-ecs\n" {
+		t.Errorf("block should be appended if there is no Host instead of %q", actual)
+	}
+}
+
+func TestWriteManagedBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "managed-block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hosts")
+	ioutil.WriteFile(file, []byte("127.0.0.1 localhost\n"), 0600)
+	if changed, err := writeManagedBlock(file, "1.1.1.1 web\n", nil, true); !changed || err != nil {
+		t.Errorf("writeManagedBlock should change file instead of %v, %v", changed, err)
+	}
+	if changed, err := writeManagedBlock(file, "1.1.1.1 web\n", nil, true); changed || err != nil {
+		t.Errorf("writeManagedBlock should not change file instead of %v, %v", changed, err)
+	}
+	if backup, _ := ioutil.ReadFile(file + ".bak"); string(backup) != "127.0.0.1 localhost\n" {
+		t.Errorf("backup should have original content instead of %q", backup)
+	}
+	if info, _ := os.Stat(file); info.Mode() != 0600 {
+		t.Errorf("file mode should be kept instead of %v", info.Mode())
+	}
+}
+
+func TestWriteManagedBlockSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "managed-block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "hosts")
+	ioutil.WriteFile(target, []byte("127.0.0.1 localhost\n"), 0640)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := writeManagedBlock(link, "1.1.1.1 web\n", nil, true); !changed || err != nil {
+		t.Fatalf("writeManagedBlock should change file instead of %v, %v", changed, err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink should be kept")
+	}
+	if content, _ := ioutil.ReadFile(target); !strings.Contains(string(content), "1.1.1.1 web") {
+		t.Errorf("target of symlink should be written instead of %q", content)
+	}
+	if info, _ := os.Stat(target); info.Mode() != 0640 {
+		t.Errorf("file mode should be kept instead of %v", info.Mode())
+	}
+	if _, err := os.Stat(target + ".bak"); err != nil {
+		t.Errorf("backup should be next to target of symlink: %s", err)
+	}
+}
@@ -26,6 +26,14 @@ var UPDATE_INSTANCE cli.Command = cli.Command{
 			Name:  "description, d",
 			Usage: "new description of the instance",
 		},
+		cli.StringFlag{
+			Name:  "incoming-bandwidth, I",
+			Usage: "new maximum incoming bandwidth in Mbps ranges from 1 to 200",
+		},
+		cli.StringFlag{
+			Name:  "outgoing-bandwidth, O",
+			Usage: "new maximum outgoing bandwidth in Mbps ranges from 1 to 200",
+		},
 	},
 	Action: func(c *cli.Context) {
 		if checkValuesForBashComplete(c) {
@@ -39,8 +47,23 @@ var UPDATE_INSTANCE cli.Command = cli.Command{
 		if c.IsSet("description") {
 			params["Description"] = c.String("description")
 		}
+		specParams := map[string]string{}
+		if c.IsSet("incoming-bandwidth") {
+			specParams["InternetMaxBandwidthIn"] = c.String("incoming-bandwidth")
+		}
+		if c.IsSet("outgoing-bandwidth") {
+			specParams["InternetMaxBandwidthOut"] = c.String("outgoing-bandwidth")
+		}
+		if len(params) == 0 && len(specParams) == 0 {
+			exit("Please provide at least one: --name, --description, --incoming-bandwidth, --outgoing-bandwidth.")
+		}
 		ForAllArgsDo([]string(c.Args()), func(arg string) {
-			Print(ECS_INSTANCE.ModifyInstanceAttributeById(arg, params))
+			if len(params) > 0 {
+				Print(ECS_INSTANCE.ModifyInstanceAttributeById(arg, params))
+			}
+			if len(specParams) > 0 {
+				Print(ECS_INSTANCE.ModifyInstanceSpecById(arg, specParams))
+			}
 		})
 	},
 	BashComplete: func(c *cli.Context) {
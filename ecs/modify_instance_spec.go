@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codegangsta/cli"
+)
+
+var RESIZE_INSTANCE cli.Command = cli.Command{
+	Name:      "resize-instance",
+	Aliases:   []string{"resize", "z"},
+	Usage:     "change type of an instance",
+	ArgsUsage: "[instance IDs...]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Usage: "new type of the instance",
+		},
+		cli.BoolFlag{
+			Name:  "restart",
+			Usage: "stop running instance before resizing and start it again after",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if checkValuesForBashComplete(c) {
+			return
+		}
+		typeId := getFirstPart(c.String("type"))
+		if typeId == "" {
+			exit("Please provide --type.")
+		}
+		types, _, err := ECS_INSTANCE.DescribeInstanceTypes()
+		if err != nil {
+			exit(err)
+		}
+		newType, ok := types.Find(typeId)
+		if !ok {
+			exit(fmt.Sprintf("Instance type %s does not exist.", typeId))
+		}
+		ForAllArgsDo([]string(c.Args()), func(arg string) {
+			instance, err := ECS_INSTANCE.DescribeInstanceAttributeById(arg)
+			if err != nil {
+				exit(err)
+			}
+			oldSpecs := "unknown"
+			if oldType, ok := types.Find(instance.InstanceType); ok {
+				oldSpecs = oldType.Specs()
+			}
+			if !IsQuiet {
+				fmt.Printf("%s (%s): %s (%s) -> %s (%s)\n", instance.InstanceName, instance.InstanceId,
+					instance.InstanceType, oldSpecs, newType.InstanceTypeId, newType.Specs())
+			}
+			Print(ECS_INSTANCE.ResizeInstanceById(instance, newType.InstanceTypeId, c.Bool("restart")))
+		})
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "resize-instance")
+		describeInstancesForBashComplete(nil)(c)
+	},
+}
+
+func (ecs *ECS) ModifyInstanceSpecById(id string, _params map[string]string) (resp ActionResponse, _ error) {
+	params := map[string]string{
+		"Action":     "ModifyInstanceSpec",
+		"InstanceId": id,
+	}
+	for k, v := range _params {
+		params[k] = v
+	}
+	if len(params) > 2 {
+		return resp, ecs.Request(params, &resp)
+	}
+	return resp, errors.New("Please provide at least one: --type, --incoming-bandwidth, --outgoing-bandwidth.")
+}
+
+// Change type of the instance. Type of a running instance can't be changed,
+// so if restart is true, the instance is stopped first and started after.
+func (ecs *ECS) ResizeInstanceById(instance ECSInstance, typeId string, restart bool) (resp ActionResponse, err error) {
+	resize := func() (err error) {
+		resp, err = ecs.ModifyInstanceSpecById(instance.InstanceId, map[string]string{
+			"InstanceType": typeId,
+		})
+		return
+	}
+	if restart {
+		err = ecs.DoWhileInstanceStopped(instance, resize)
+	} else {
+		err = resize()
+	}
+	return
+}
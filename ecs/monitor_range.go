@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/aliyun/ecs/errors"
+)
+
+// max number of datapoints returned by one request of monitor data
+const MONITOR_DATA_LIMIT = 400
+
+// max number of requests of monitor data at the same time
+const MONITOR_DATA_CONCURRENCY = 4
+
+// Split time range into windows that each has no more datapoints of the
+// period than the limit.
+func monitorWindows(startTime, endTime time.Time, period int) (windows [][2]time.Time) {
+	span := time.Duration(MONITOR_DATA_LIMIT*period) * time.Second
+	for start := startTime; start.Before(endTime); start = start.Add(span) {
+		end := start.Add(span)
+		if end.After(endTime) {
+			end = endTime
+		}
+		windows = append(windows, [2]time.Time{start, end})
+	}
+	if len(windows) == 0 {
+		windows = append(windows, [2]time.Time{startTime, endTime})
+	}
+	return
+}
+
+// Call do with index of every window concurrently.
+func forMonitorWindows(windows [][2]time.Time, do func(i int) error) (err error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errs errors.Errors
+	sem := make(chan bool, MONITOR_DATA_CONCURRENCY)
+	for i := range windows {
+		wg.Add(1)
+		go func(i int) {
+			sem <- true
+			err := do(i)
+			<-sem
+			if err != nil {
+				mutex.Lock()
+				errs.Add(err)
+				mutex.Unlock()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	if errs.HaveError() {
+		err = errs.Errorify()
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func testMonitorWindows(t *testing.T, hours, period, expected int) {
+	end := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+	start := end.Add(time.Duration(-hours) * time.Hour)
+	windows := monitorWindows(start, end, period)
+	if len(windows) != expected {
+		t.Errorf("%d hours of period %d should be split into %d windows instead of %d", hours, period, expected, len(windows))
+		return
+	}
+	if !windows[0][0].Equal(start) || !windows[len(windows)-1][1].Equal(end) {
+		t.Errorf("windows of %d hours should cover the whole range", hours)
+	}
+	for i, window := range windows {
+		if window[1].Sub(window[0]).Seconds()/float64(period) > MONITOR_DATA_LIMIT {
+			t.Errorf("window %d has too many datapoints", i)
+		}
+		if i > 0 && !window[0].Equal(windows[i-1][1]) {
+			t.Errorf("window %d should begin at the end of window %d", i, i-1)
+		}
+	}
+}
+
+func TestMonitorWindows(t *testing.T) {
+	testMonitorWindows(t, 1, 60, 1)
+	testMonitorWindows(t, 6, 60, 1)
+	testMonitorWindows(t, 7, 60, 2)
+	testMonitorWindows(t, 24, 60, 4)
+	testMonitorWindows(t, 24*7, 600, 3)
+	testMonitorWindows(t, 24*30, 3600, 2)
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2016, 6, 1, 8, 30, 0, 0, time.UTC)
+	for _, input := range []string{"2016-06-01T08:30:00Z", "2016-06-01T16:30:00+08:00"} {
+		actual, err := parseTime(input)
+		if err != nil || !actual.Equal(expected) {
+			t.Errorf("%s should be parsed as %s instead of %s", input, expected, actual)
+		}
+	}
+	if _, err := parseTime("yesterday"); err == nil {
+		t.Error("yesterday should not be parsed")
+	}
+}
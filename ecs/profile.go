@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Profile holds defaults of global options. Profiles are read from
+// ~/.aliyun/ecs.json (or ECS_CONFIG) which is an object of profile names
+// to profiles, like:
+//
+//	{"default": {"regions": ["cn-hangzhou", "cn-qingdao"], "signature_version": "v3"}}
+type Profile struct {
+	Regions          []string `json:"regions"`
+	SignatureVersion string   `json:"signature_version"`
+}
+
+const DEFAULT_PROFILE = "default"
+
+var PROFILE Profile
+var ProfileName string
+
+// Regions to limit ForAllRegionsDo to, all regions if empty.
+var Regions []string
+
+func profileFile() string {
+	if file := os.Getenv("ECS_CONFIG"); file != "" {
+		return file
+	}
+	return filepath.Join(os.Getenv("HOME"), ".aliyun", "ecs.json")
+}
+
+// Read profile of name from file. It is not an error if file does not exist
+// or has no default profile.
+func loadProfile(file, name string) (profile Profile, err error) {
+	var content []byte
+	content, err = ioutil.ReadFile(file)
+	if os.IsNotExist(err) && name == DEFAULT_PROFILE {
+		return profile, nil
+	}
+	if err != nil {
+		return
+	}
+	var profiles map[string]Profile
+	if err = json.Unmarshal(content, &profiles); err != nil {
+		return profile, fmt.Errorf("%s: %s", file, err)
+	}
+	profile, ok := profiles[name]
+	if !ok && name != DEFAULT_PROFILE {
+		err = fmt.Errorf("No profile named %s in %s.", name, file)
+	}
+	return
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ecs.json")
+	if _, err := loadProfile(file, DEFAULT_PROFILE); err != nil {
+		t.Errorf("default profile should be optional: %s", err)
+	}
+	if _, err := loadProfile(file, "prod"); err == nil {
+		t.Error("profile prod should not exist")
+	}
+	ioutil.WriteFile(file, []byte(`{"prod": {"regions": ["cn-hangzhou"]}}`), 0600)
+	profile, err := loadProfile(file, "prod")
+	if err != nil || !reflect.DeepEqual(profile.Regions, []string{"cn-hangzhou"}) {
+		t.Errorf("profile prod should have regions [cn-hangzhou] instead of %v, %v", profile.Regions, err)
+	}
+	if _, err := loadProfile(file, DEFAULT_PROFILE); err != nil {
+		t.Errorf("default profile should be optional: %s", err)
+	}
+}
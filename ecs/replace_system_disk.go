@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+)
+
+type ReplaceSystemDisk struct {
+	DiskId    string `json:"DiskId"`
+	RequestId string `json:"RequestId"`
+}
+
+var REINSTALL_INSTANCE cli.Command = cli.Command{
+	Name:      "reinstall-instance",
+	Aliases:   []string{"reinstall"},
+	Usage:     "replace system disk of an instance with a new one of an image",
+	ArgsUsage: "[instance IDs...]",
+	Description: `All data on the system disk will be lost. Running instances are stopped
+   first and started again after the system disk is replaced.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "image, i",
+			Usage: "reinstall using this image",
+		},
+		cli.StringFlag{
+			Name:   "password, p",
+			Usage:  "new password of the instance, can be specified from env var",
+			EnvVar: "PASSWORD",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "do not ask for confirmation",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if checkValuesForBashComplete(c) {
+			return
+		}
+		image := c.String("image")
+		if image == "" {
+			exit("Please provide --image.")
+		}
+		ForAllArgsDo([]string(c.Args()), func(arg string) {
+			instance, err := ECS_INSTANCE.DescribeInstanceAttributeById(arg)
+			if err != nil {
+				exit(err)
+			}
+			question := fmt.Sprintf("Reinstall %s (%s) with %s? All data on its system disk will be lost.",
+				instance.InstanceName, instance.InstanceId, image)
+			if !c.Bool("yes") && !confirm(ECS_INSTANCE.Context(), question) {
+				return
+			}
+			Print(ECS_INSTANCE.ReplaceSystemDiskById(instance, image, c.String("password")))
+		})
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "reinstall-instance")
+		describeInstancesForBashComplete(nil)(c)
+	},
+}
+
+func (ecs *ECS) ReplaceSystemDiskById(instance ECSInstance, image, password string) (replace ReplaceSystemDisk, err error) {
+	params := map[string]string{
+		"Action":     "ReplaceSystemDisk",
+		"InstanceId": instance.InstanceId,
+		"ImageId":    image,
+	}
+	if password != "" {
+		params["Password"] = password
+	}
+	err = ecs.DoWhileInstanceStopped(instance, func() error {
+		return ecs.Request(params, &replace)
+	})
+	return
+}
+
+func (replace ReplaceSystemDisk) Print() {
+	fmt.Println(replace.DiskId)
+}
+
+func (replace ReplaceSystemDisk) PrintTable() {
+	fmt.Println(replace.DiskId)
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const HOURS_PER_MONTH = 730
+
+var REPORT cli.Command = cli.Command{
+	Name:  "report",
+	Usage: "show reports of instances",
+	Subcommands: []cli.Command{
+		REPORT_USAGE,
+	},
+}
+
+var REPORT_USAGE cli.Command = cli.Command{
+	Name:      "usage",
+	Usage:     "show CPU and network usage of instances and suggest types that fit",
+	ArgsUsage: "[instance IDs...]",
+	Description: `Memory usage is unknown, so suggested types have at least --memory percent
+   of memory of current types. Savings are estimated with pay-as-you-go prices.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "days, d",
+			Value: 14,
+			Usage: "use stats of last N days",
+		},
+		cli.Float64Flag{
+			Name:  "headroom",
+			Value: 30,
+			Usage: "percentage of CPU to keep free at p95 usage on suggested type",
+		},
+		cli.Float64Flag{
+			Name:  "memory",
+			Value: 100,
+			Usage: "minimum percentage of current memory to keep on suggested type",
+		},
+		cli.Float64Flag{
+			Name:  "idle-cpu",
+			Value: 5,
+			Usage: "instance with p95 CPU usage below this percentage may be idle",
+		},
+		cli.Float64Flag{
+			Name:  "idle-traffic",
+			Value: 100,
+			Usage: "instance with internet traffic below this MB per day may be idle",
+		},
+		cli.BoolFlag{
+			Name:  "csv",
+			Usage: "print in CSV format",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if c.Int("days") < 1 {
+			exit("Please provide --days of at least 1.")
+		}
+		usages, err := ECS_INSTANCE.DescribeInstanceUsages([]string(c.Args()), c.Int("days"), c.Float64("headroom"), c.Float64("memory"))
+		if err != nil {
+			exit(err)
+		}
+		idleTraffic := c.Float64("idle-traffic") * (1 << 20) * float64(c.Int("days"))
+		failed := 0
+		for i := range usages {
+			if usages[i].Error != "" {
+				failed++
+				continue
+			}
+			usages[i].Idle = usages[i].CPUP95 < c.Float64("idle-cpu") && usages[i].TrafficIn+usages[i].TrafficOut < idleTraffic
+		}
+		if c.Bool("csv") {
+			usages.PrintCSV()
+		} else {
+			Print(usages, nil)
+		}
+		if failed > 0 {
+			exit(fmt.Sprintf("Failed to get stats of %d instance(s).", failed))
+		}
+	},
+}
+
+type InstanceUsage struct {
+	Instance   ECSInstance
+	Type       ECSInstanceType
+	Suggested  *ECSInstanceType
+	CPUP50     float64
+	CPUP95     float64
+	CPUMax     float64
+	TrafficIn  float64 // bytes
+	TrafficOut float64 // bytes
+	Idle       bool
+	Savings    float64 // per month
+	Currency   string
+	HasSavings bool
+	// stats of the instance are unknown if not empty
+	Error string `json:",omitempty"`
+}
+
+type InstanceUsages []InstanceUsage
+
+func (a InstanceUsages) Len() int      { return len(a) }
+func (a InstanceUsages) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a InstanceUsages) Less(i, j int) bool {
+	if a[i].Savings != a[j].Savings {
+		return a[i].Savings > a[j].Savings
+	}
+	return a[i].freedCores() > a[j].freedCores()
+}
+
+func (usage InstanceUsage) freedCores() int64 {
+	if usage.Suggested == nil {
+		return 0
+	}
+	return usage.Type.CpuCoreCount - usage.Suggested.CpuCoreCount
+}
+
+// Find the smallest type that has enough CPU cores to keep p95 CPU usage
+// below (100 - headroom)% and at least memory% of memory of current type.
+// Types must be sorted.
+func suggestInstanceType(types ECSInstanceTypes, current ECSInstanceType, cpuP95, headroom, memory float64) *ECSInstanceType {
+	target := 1 - headroom/100
+	if target <= 0 {
+		target = 0.01
+	}
+	cores := int64(math.Ceil(float64(current.CpuCoreCount) * cpuP95 / 100 / target))
+	if cores < 1 {
+		cores = 1
+	}
+	for i := range types {
+		if types[i].CpuCoreCount >= cores && types[i].MemorySize >= current.MemorySize*memory/100 {
+			return &types[i]
+		}
+	}
+	return nil
+}
+
+func (ecs *ECS) DescribeInstanceUsages(ids []string, days int, headroom, memory float64) (usages InstanceUsages, err error) {
+	var types ECSInstanceTypes
+	types, _, err = ecs.DescribeInstanceTypes()
+	if err != nil {
+		return
+	}
+	var instances ECSInstances
+	if len(ids) > 0 {
+		for _, id := range ids {
+			var instance ECSInstance
+			instance, err = ecs.DescribeInstanceAttributeById(getFirstPart(id))
+			if err != nil {
+				return
+			}
+			instances = append(instances, instance)
+		}
+	} else {
+		instances, err = ecs.DescribeInstances()
+		if err != nil {
+			return
+		}
+	}
+
+	end := time.Now().UTC()
+	start := end.Add(-time.Duration(days) * 24 * time.Hour)
+	prices := newPriceCache(ecs)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan bool, 4)
+	for _, instance := range instances {
+		wg.Add(1)
+		go func(instance ECSInstance) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+			data, _, err := ecs.DescribeInstanceMonitorData(instance.InstanceId, start, end, 3600)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, instance.InstanceId+":", err)
+				mutex.Lock()
+				usages = append(usages, InstanceUsage{Instance: instance, Error: err.Error()})
+				mutex.Unlock()
+				return
+			}
+			usage := InstanceUsage{Instance: instance}
+			usage.Type, _ = types.Find(instance.InstanceType)
+			var cpu []float64
+			for _, datum := range data {
+				cpu = append(cpu, float64(datum.CPU))
+				usage.TrafficIn += float64(datum.InternetRX) * 1024 / 8
+				usage.TrafficOut += float64(datum.InternetTX) * 1024 / 8
+			}
+			_, _, usage.CPUMax, usage.CPUP95 = summarize(cpu)
+			usage.CPUP50 = percentile(cpu, 50)
+			if usage.Type.InstanceTypeId != "" {
+				usage.Suggested = suggestInstanceType(types, usage.Type, usage.CPUP95, headroom, memory)
+			}
+			if usage.Suggested != nil && usage.Suggested.InstanceTypeId != usage.Type.InstanceTypeId {
+				current, currency, err1 := prices.Get(instance.RegionId, usage.Type.InstanceTypeId)
+				suggested, _, err2 := prices.Get(instance.RegionId, usage.Suggested.InstanceTypeId)
+				if err1 == nil && err2 == nil {
+					usage.Savings = (current - suggested) * HOURS_PER_MONTH
+					usage.Currency = currency
+					usage.HasSavings = true
+				}
+			}
+			mutex.Lock()
+			usages = append(usages, usage)
+			mutex.Unlock()
+		}(instance)
+	}
+	wg.Wait()
+	sort.Sort(usages)
+	return
+}
+
+type priceCache struct {
+	ecs    *ECS
+	mutex  sync.Mutex
+	prices map[string]float64
+	errors map[string]error
+	unit   string
+}
+
+func newPriceCache(ecs *ECS) *priceCache {
+	return &priceCache{ecs: ecs, prices: map[string]float64{}, errors: map[string]error{}}
+}
+
+func (cache *priceCache) Get(region, typeId string) (price float64, currency string, err error) {
+	key := region + "/" + typeId
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if err = cache.errors[key]; err != nil {
+		return
+	}
+	if price, ok := cache.prices[key]; ok {
+		return price, cache.unit, nil
+	}
+	price, currency, err = cache.ecs.DescribeInstanceTypePrice(region, typeId)
+	if err != nil {
+		cache.errors[key] = err
+		return
+	}
+	cache.prices[key] = price
+	cache.unit = currency
+	return
+}
+
+func (usage InstanceUsage) row() []string {
+	if usage.Error != "" {
+		return []string{
+			usage.Instance.InstanceId,
+			usage.Instance.InstanceName,
+			usage.Instance.InstanceType,
+			"?", "?", "?", "?", "?", "?",
+			"-",
+			"-",
+		}
+	}
+	suggested, savings, idle := "-", "-", "No"
+	if usage.Suggested != nil && usage.Suggested.InstanceTypeId != usage.Type.InstanceTypeId {
+		suggested = fmt.Sprintf("%s (%s)", usage.Suggested.InstanceTypeId, usage.Suggested.Specs())
+	}
+	if usage.HasSavings {
+		savings = fmt.Sprintf("%.2f %s", usage.Savings, usage.Currency)
+	}
+	if usage.Idle {
+		idle = "Yes"
+	}
+	return []string{
+		usage.Instance.InstanceId,
+		usage.Instance.InstanceName,
+		usage.Instance.InstanceType,
+		fmt.Sprintf("%.0f%%", usage.CPUP50),
+		fmt.Sprintf("%.0f%%", usage.CPUP95),
+		fmt.Sprintf("%.0f%%", usage.CPUMax),
+		humanBytes(usage.TrafficIn),
+		humanBytes(usage.TrafficOut),
+		idle,
+		suggested,
+		savings,
+	}
+}
+
+var usageFields = []interface{}{"ID", "Name", "Type", "CPU p50", "CPU p95", "CPU Max", "Received", "Sent", "Idle", "Suggested", "Savings/Month"}
+
+func (usages InstanceUsages) Print() {
+	for _, usage := range usages {
+		if usage.Idle || (usage.Suggested != nil && usage.Suggested.InstanceTypeId != usage.Type.InstanceTypeId) {
+			fmt.Println(usage.Instance.InstanceId)
+		}
+	}
+}
+
+func (usages InstanceUsages) PrintTable() {
+	PrintTable(
+		/* fields     */ usageFields,
+		/* showFields */ true,
+		/* listLength */ len(usages),
+		/* filter     */ nil,
+		/* getInfo    */ func(i int) map[interface{}]interface{} {
+			info := map[interface{}]interface{}{}
+			for j, value := range usages[i].row() {
+				info[usageFields[j]] = value
+			}
+			return info
+		},
+	)
+}
+
+func (usages InstanceUsages) PrintCSV() {
+	w := csv.NewWriter(os.Stdout)
+	header := make([]string, len(usageFields))
+	for i, field := range usageFields {
+		header[i] = field.(string)
+	}
+	w.Write(header)
+	for _, usage := range usages {
+		w.Write(usage.row())
+	}
+	w.Flush()
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+var testInstanceTypes = ECSInstanceTypes{
+	{InstanceTypeId: "ecs.t1.small", CpuCoreCount: 1, MemorySize: 1},
+	{InstanceTypeId: "ecs.s1.small", CpuCoreCount: 1, MemorySize: 2},
+	{InstanceTypeId: "ecs.s2.large", CpuCoreCount: 2, MemorySize: 4},
+	{InstanceTypeId: "ecs.s3.large", CpuCoreCount: 4, MemorySize: 8},
+	{InstanceTypeId: "ecs.m2.xlarge", CpuCoreCount: 4, MemorySize: 16},
+}
+
+func testSuggestInstanceType(t *testing.T, current string, cpuP95, headroom, memory float64, expected string) {
+	itype, _ := testInstanceTypes.Find(current)
+	suggested := suggestInstanceType(testInstanceTypes, itype, cpuP95, headroom, memory)
+	actual := ""
+	if suggested != nil {
+		actual = suggested.InstanceTypeId
+	}
+	if actual != expected {
+		t.Errorf("suggestInstanceType(%s, %v, %v, %v) should be %q instead of %q", current, cpuP95, headroom, memory, expected, actual)
+	}
+}
+
+func TestSuggestInstanceType(t *testing.T) {
+	testSuggestInstanceType(t, "ecs.s3.large", 10, 30, 100, "ecs.s3.large")
+	testSuggestInstanceType(t, "ecs.s3.large", 10, 30, 50, "ecs.s2.large")
+	testSuggestInstanceType(t, "ecs.s3.large", 10, 30, 0, "ecs.t1.small")
+	testSuggestInstanceType(t, "ecs.m2.xlarge", 30, 30, 50, "ecs.s3.large")
+	testSuggestInstanceType(t, "ecs.s1.small", 90, 30, 100, "ecs.s2.large")
+	testSuggestInstanceType(t, "ecs.t1.small", 5, 30, 100, "ecs.t1.small")
+	testSuggestInstanceType(t, "ecs.m2.xlarge", 100, 30, 100, "")
+}
+
+func TestInstanceUsageRowError(t *testing.T) {
+	usage := InstanceUsage{Instance: ECSInstance{InstanceId: "i-1"}, Error: "oops"}
+	row := usage.row()
+	if len(row) != len(usageFields) {
+		t.Fatalf("row should have %d columns instead of %d", len(usageFields), len(row))
+	}
+	if row[0] != "i-1" || row[4] != "?" {
+		t.Errorf("stats of instance with error should be unknown: %v", row)
+	}
+}
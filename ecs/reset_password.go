@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+)
+
+var RESET_PASSWORD cli.Command = cli.Command{
+	Name:      "reset-password",
+	Aliases:   []string{"passwd"},
+	Usage:     "reset password of an instance",
+	ArgsUsage: "[instance IDs...]",
+	Description: `Running instances are stopped first and started again after the password
+   is changed, because new password takes effect only after restart.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:   "password, p",
+			Usage:  "new password of the instance, can be specified from env var, prompts if empty",
+			EnvVar: "PASSWORD",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "do not ask for confirmation",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if checkValuesForBashComplete(c) {
+			return
+		}
+		if !c.Args().Present() {
+			exit("Please provide at least one instance.")
+		}
+		password := c.String("password")
+		if password == "" {
+			var err error
+			password, err = readPassword(ECS_INSTANCE.Context(), "New password: ")
+			if err != nil {
+				exit(err)
+			}
+			retyped, err := readPassword(ECS_INSTANCE.Context(), "Retype new password: ")
+			if err != nil {
+				exit(err)
+			}
+			if password != retyped {
+				exit("Passwords do not match.")
+			}
+		}
+		ForAllArgsDo([]string(c.Args()), func(arg string) {
+			instance, err := ECS_INSTANCE.DescribeInstanceAttributeById(arg)
+			if err != nil {
+				exit(err)
+			}
+			question := fmt.Sprintf("Reset password of %s (%s)?", instance.InstanceName, instance.InstanceId)
+			if instance.Status == "Running" {
+				question = fmt.Sprintf("Reset password of %s (%s)? It will be restarted.", instance.InstanceName, instance.InstanceId)
+			}
+			if !c.Bool("yes") && !confirm(ECS_INSTANCE.Context(), question) {
+				return
+			}
+			Print(ECS_INSTANCE.ResetPasswordById(instance, password))
+		})
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "reset-password")
+		describeInstancesForBashComplete(nil)(c)
+	},
+}
+
+func (ecs *ECS) ResetPasswordById(instance ECSInstance, password string) (modify ModifyInstanceAttribute, err error) {
+	err = ecs.DoWhileInstanceStopped(instance, func() (err error) {
+		modify, err = ecs.ModifyInstanceAttributeById(instance.InstanceId, map[string]string{
+			"Password": password,
+		})
+		return
+	})
+	return
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signature versions of requests, selectable with --signature-version or
+// signature_version of profile.
+const (
+	// HMAC-SHA1 signature of GET query string
+	SIGNATURE_V1 = "v1"
+	// ACS3-HMAC-SHA256 signature of POST body and canonical headers
+	SIGNATURE_V3 = "v3"
+)
+
+const ECS_HOST = "ecs.aliyuncs.com"
+const ECS_API_VERSION = "2014-05-26"
+
+var SignatureVersion = SIGNATURE_V1
+
+func (ecs *ECS) newRequest(queries map[string]string) (*http.Request, error) {
+	switch SignatureVersion {
+	case SIGNATURE_V1:
+		url, _ := ecs.signedURL(queries)
+		return http.NewRequest("GET", url, nil)
+	case SIGNATURE_V3:
+		return ecs.newRequestV3(queries, time.Now().UTC(), randomString(32))
+	}
+	return nil, fmt.Errorf("Unknown signature version %s.", SignatureVersion)
+}
+
+// Get URL of request and all parameters including the signature.
+func (ecs *ECS) signedURL(queries map[string]string) (url string, params map[string]string) {
+	params = map[string]string{
+		"Format":           "JSON",
+		"Version":          ECS_API_VERSION,
+		"AccessKeyId":      ecs.KEY,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   randomString(64),
+		"Timestamp":        time.Now().UTC().Format(TIME_FORMAT),
+		"PageSize":         "50",
+		"PageNumber":       "1",
+	}
+	for k, v := range queries {
+		params[k] = v
+	}
+	query := buildQueryString(params)
+	signature := sign(ecs.SECRET, urlEncode(query))
+	url = fmt.Sprintf("http://%s/?%s&Signature=%s", ECS_HOST, query, urlEncode(signature))
+	params["Signature"] = signature
+	return
+}
+
+// Build POST request with parameters other than Action and Version in the
+// form body, signed with ACS3-HMAC-SHA256.
+func (ecs *ECS) newRequestV3(queries map[string]string, date time.Time, nonce string) (*http.Request, error) {
+	params := map[string]string{
+		"PageSize":   "50",
+		"PageNumber": "1",
+	}
+	for k, v := range queries {
+		params[k] = v
+	}
+	action, version := params["Action"], params["Version"]
+	if version == "" {
+		version = ECS_API_VERSION
+	}
+	delete(params, "Action")
+	delete(params, "Version")
+	body := buildQueryString(params)
+	headers := map[string]string{
+		"host":                  ECS_HOST,
+		"content-type":          "application/x-www-form-urlencoded",
+		"x-acs-action":          action,
+		"x-acs-version":         version,
+		"x-acs-date":            date.Format(TIME_FORMAT),
+		"x-acs-signature-nonce": nonce,
+		"x-acs-content-sha256":  sha256Hex([]byte(body)),
+	}
+	headers["authorization"] = signV3(ecs.KEY, ecs.SECRET, "POST", "/", nil, headers, headers["x-acs-content-sha256"])
+	req, err := http.NewRequest("POST", "http://"+ECS_HOST+"/", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		if key != "host" {
+			req.Header.Set(key, value)
+		}
+	}
+	return req, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Canonical request of ACS3-HMAC-SHA256 with hex SHA-256 of the payload.
+// Headers must have lower-case names, all of host, content-type and x-acs-*
+// headers are signed.
+func canonicalRequestV3(method, path string, query, headers map[string]string, hashedPayload string) (canonical, signedHeaders string) {
+	var names []string
+	for name := range headers {
+		if name == "host" || name == "content-type" || strings.HasPrefix(name, "x-acs-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + strings.TrimSpace(headers[name]) + "\n"
+	}
+	signedHeaders = strings.Join(names, ";")
+	canonical = strings.Join([]string{
+		method,
+		path,
+		buildQueryString(query),
+		canonicalHeaders,
+		signedHeaders,
+		hashedPayload,
+	}, "\n")
+	return
+}
+
+// Get value of Authorization header of ACS3-HMAC-SHA256.
+func signV3(key, secret, method, path string, query, headers map[string]string, hashedPayload string) string {
+	canonical, signedHeaders := canonicalRequestV3(method, path, query, headers, hashedPayload)
+	stringToSign := "ACS3-HMAC-SHA256\n" + sha256Hex([]byte(canonical))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return fmt.Sprintf("ACS3-HMAC-SHA256 Credential=%s,SignedHeaders=%s,Signature=%s",
+		key, signedHeaders, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Example of Alibaba Cloud documentation of signature V1.
+func TestSignV1(t *testing.T) {
+	query := buildQueryString(map[string]string{
+		"AccessKeyId":      "testid",
+		"Action":           "DescribeRegions",
+		"Format":           "XML",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+		"SignatureVersion": "1.0",
+		"Timestamp":        "2016-02-23T12:46:24Z",
+		"Version":          "2014-05-26",
+	})
+	expected := "OLeaidS1JvxuMvnyHOwuJ+uX5qY="
+	if actual := sign("testsecret", urlEncode(query)); actual != expected {
+		t.Errorf("signature should be %s instead of %s", expected, actual)
+	}
+}
+
+// Test vector of Alibaba Cloud OpenAPI util of signature V3, header names of
+// different cases are combined and empty query values are kept.
+func TestSignV3(t *testing.T) {
+	query := map[string]string{
+		"test":  "ok",
+		"empty": "",
+	}
+	headers := map[string]string{
+		"x-acs-test": "http,https",
+	}
+	hashedPayload := "55e12e91650d2fec56ec74e1d3e4ddbfce2ef3a65890c2a19ecf88a307e76a23"
+	expected := "ACS3-HMAC-SHA256 Credential=acesskey,SignedHeaders=x-acs-test," +
+		"Signature=4ab59fffe3c5738ff8a2729f90cc04fe18b02a4b15b2102cbaf92f9ff3df2ea3"
+	if actual := signV3("acesskey", "secret", "", "/", query, headers, hashedPayload); actual != expected {
+		t.Errorf("authorization should be %s instead of %s", expected, actual)
+	}
+}
+
+func TestNewRequestV3(t *testing.T) {
+	ecs := &ECS{KEY: "key", SECRET: "secret"}
+	date := time.Date(2023, 10, 26, 10, 22, 32, 0, time.UTC)
+	req, err := ecs.newRequestV3(map[string]string{
+		"Action":     "DescribeInstances",
+		"RegionId":   "cn-beijing",
+		"PageNumber": "2",
+	}, date, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL.RawQuery != "" {
+		t.Errorf("parameters should be posted instead of %s %s", req.Method, req.URL)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := url.Values{"RegionId": {"cn-beijing"}, "PageNumber": {"2"}, "PageSize": {"50"}}
+	if !reflect.DeepEqual(form, expected) {
+		t.Errorf("body should be %v instead of %v", expected, form)
+	}
+	if action := req.Header.Get("x-acs-action"); action != "DescribeInstances" {
+		t.Errorf("x-acs-action should be DescribeInstances instead of %s", action)
+	}
+	if version := req.Header.Get("x-acs-version"); version != ECS_API_VERSION {
+		t.Errorf("x-acs-version should be %s instead of %s", ECS_API_VERSION, version)
+	}
+	sum := sha256.Sum256(body)
+	if expected, actual := hex.EncodeToString(sum[:]), req.Header.Get("x-acs-content-sha256"); actual != expected {
+		t.Errorf("x-acs-content-sha256 should be %s instead of %s", expected, actual)
+	}
+	headers := map[string]string{"host": ECS_HOST}
+	for name := range req.Header {
+		if name = strings.ToLower(name); name != "authorization" {
+			headers[name] = req.Header.Get(name)
+		}
+	}
+	auth := signV3("key", "secret", "POST", "/", nil, headers, hex.EncodeToString(sum[:]))
+	if actual := req.Header.Get("authorization"); actual != auth {
+		t.Errorf("authorization should be %s instead of %s", auth, actual)
+	}
+	if !strings.Contains(auth, "SignedHeaders=content-type;host;x-acs-action;x-acs-content-sha256;x-acs-date;x-acs-signature-nonce;x-acs-version,") {
+		t.Errorf("all headers should be signed: %s", auth)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+)
+
+var SSH_FLAGS = []cli.Flag{
+	cli.StringFlag{
+		Name:   "user, u",
+		Value:  "root",
+		Usage:  "log in as this user",
+		EnvVar: "ECS_SSH_USER",
+	},
+	cli.BoolFlag{
+		Name:  "private, p",
+		Usage: "connect to private IP address",
+	},
+	cli.StringFlag{
+		Name:   "bastion, b",
+		Usage:  "jump through this host (instance name or [user@]host) to private IP address",
+		EnvVar: "ECS_SSH_BASTION",
+	},
+}
+
+var SSH cli.Command = cli.Command{
+	Name:      "ssh",
+	Usage:     "connect to instance with ssh",
+	ArgsUsage: "NAME [ssh arguments...]",
+	Description: `NAME can be instance name or ID. Public IP address is used unless --private
+   or --bastion is set, or the instance has no public IP address.`,
+	Flags: SSH_FLAGS,
+	Action: func(c *cli.Context) {
+		if !c.Args().Present() {
+			exit("Please specify name or ID of the instance.")
+		}
+		instances, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			exit(err)
+		}
+		instance, err := instances.Find(c.Args().First())
+		if err != nil {
+			exit(err)
+		}
+		bastion, err := resolveBastion(instances, c.String("bastion"), c.String("user"))
+		if err != nil {
+			exit(err)
+		}
+		host, jump := sshHost(instance, c.Bool("private"), bastion)
+		if host == "" {
+			exit("Instance has no IP address.")
+		}
+		args := []string{"ssh"}
+		if jump != "" {
+			args = append(args, "-J", jump)
+		}
+		args = append(args, c.String("user")+"@"+host)
+		args = append(args, c.Args().Tail()...)
+		ssh, err := exec.LookPath("ssh")
+		if err != nil {
+			exit(err)
+		}
+		if IsVerbose {
+			fmt.Fprintln(os.Stderr, args)
+		}
+		exit(syscall.Exec(ssh, args, os.Environ()))
+	},
+	BashComplete: func(c *cli.Context) {
+		printFlagsForCommand(c, "ssh")
+		instances, _ := ECS_INSTANCE.DescribeInstances()
+		for _, instance := range instances {
+			fmt.Println(instance.InstanceName)
+		}
+	},
+}
+
+// Start of sections of ssh config. The first value found is used, so entries
+// are put before them to not be overridden by sections like Host *.
+var SSH_CONFIG_SECTION = regexp.MustCompile(`(?im)^[ \t]*(host|match)[ \t=]`)
+
+var SSH_CONFIG cli.Command = cli.Command{
+	Name:      "ssh-config",
+	Usage:     "print or write Host entries of instances for ~/.ssh/config",
+	ArgsUsage: " ",
+	Description: `Entries are put between "# BEGIN aliyun-ecs" and "# END aliyun-ecs" lines,
+   which are replaced on each --write and kept before the first Host or Match
+   so that sections like Host * don't override them. Hosts without public IP address jump
+   through --bastion if it is set.`,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "write, w",
+			Usage: "write entries to --file instead of printing them",
+		},
+		cli.StringFlag{
+			Name:  "file",
+			Value: filepath.Join(os.Getenv("HOME"), ".ssh", "config"),
+			Usage: "ssh config file to write",
+		},
+	}, SSH_FLAGS...),
+	Action: func(c *cli.Context) {
+		instances, err := ECS_INSTANCE.DescribeInstances()
+		if err != nil {
+			exit(err)
+		}
+		bastion, err := resolveBastion(instances, c.String("bastion"), c.String("user"))
+		if err != nil {
+			exit(err)
+		}
+		config := sshConfig(instances, c.String("user"), c.Bool("private"), bastion)
+		if !c.Bool("write") {
+			fmt.Print(config)
+			return
+		}
+		changed, err := writeManagedBlock(c.String("file"), config, SSH_CONFIG_SECTION, false)
+		if err != nil {
+			exit(err)
+		}
+		if changed {
+			fmt.Println("Updated", c.String("file"))
+		} else {
+			fmt.Println(c.String("file"), "is up to date")
+		}
+	},
+}
+
+// Find instance by ID or name (or ID@name from bash completion).
+func (instances ECSInstances) Find(nameOrId string) (instance ECSInstance, err error) {
+	id := getFirstPart(nameOrId)
+	var found ECSInstances
+	for _, instance := range instances {
+		if instance.InstanceId == id {
+			return instance, nil
+		}
+		if instance.InstanceName == nameOrId {
+			found = append(found, instance)
+		}
+	}
+	switch len(found) {
+	case 0:
+		err = fmt.Errorf("No instance named %s.", nameOrId)
+	case 1:
+		instance = found[0]
+	default:
+		err = fmt.Errorf("There are %d instances named %s, please use instance ID instead.", len(found), nameOrId)
+	}
+	return
+}
+
+// Bastion can be an instance name or ID, whose public IP address is used, or
+// any host ssh accepts.
+func resolveBastion(instances ECSInstances, bastion, user string) (string, error) {
+	if bastion == "" {
+		return "", nil
+	}
+	instance, err := instances.Find(bastion)
+	if err != nil {
+		return bastion, nil
+	}
+	if instance.PublicIP() == "" {
+		return "", fmt.Errorf("Bastion %s has no public IP address.", bastion)
+	}
+	return user + "@" + instance.PublicIP(), nil
+}
+
+// Get the address to connect to and the host to jump through, if any.
+func sshHost(instance ECSInstance, private bool, bastion string) (host, jump string) {
+	if !private && bastion == "" && instance.PublicIP() != "" {
+		return instance.PublicIP(), ""
+	}
+	if bastion == "" && instance.PrivateIP() == "" {
+		return instance.PublicIP(), ""
+	}
+	return instance.PrivateIP(), bastion
+}
+
+var invalidHostNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Replace characters not allowed in host names with dashes.
+func toHostName(name string) string {
+	return invalidHostNameRegexp.ReplaceAllString(name, "-")
+}
+
+func sshConfig(instances ECSInstances, user string, private bool, bastion string) string {
+	var config bytes.Buffer
+	for _, instance := range instances {
+		if !shouldShow(instance) || instance.InstanceName == "" {
+			continue
+		}
+		// only hosts without public IP address need the bastion
+		jumpVia := bastion
+		if !private && instance.PublicIP() != "" {
+			jumpVia = ""
+		}
+		host, jump := sshHost(instance, private || jumpVia != "", jumpVia)
+		if host == "" {
+			continue
+		}
+		fmt.Fprintf(&config, "Host %s\n", toHostName(instance.InstanceName))
+		fmt.Fprintf(&config, "    HostName %s\n", host)
+		fmt.Fprintf(&config, "    User %s\n", user)
+		if jump != "" {
+			fmt.Fprintf(&config, "    ProxyJump %s\n", jump)
+		}
+	}
+	return config.String()
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func testInstance(id, name, public, private string) (instance ECSInstance) {
+	instance.InstanceId = id
+	instance.InstanceName = name
+	if public != "" {
+		instance.PublicIpAddress.IpAddress = []string{public}
+	}
+	instance.VpcAttributes.PrivateIpAddress.IpAddress = []string{private}
+	return
+}
+
+func TestSSHConfig(t *testing.T) {
+	instances := ECSInstances{
+		testInstance("i-1", "web 01", "1.1.1.1", "10.0.0.1"),
+		testInstance("i-2", "db-01", "", "10.0.0.2"),
+	}
+	expected := `Host web-01
+    HostName 1.1.1.1
+    User root
+Host db-01
+    HostName 10.0.0.2
+    User root
+    ProxyJump root@1.1.1.1
+`
+	if actual := sshConfig(instances, "root", false, "root@1.1.1.1"); actual != expected {
+		t.Errorf("sshConfig should be %q instead of %q", expected, actual)
+	}
+	if _, err := instances.Find("web 01"); err != nil {
+		t.Error(err)
+	}
+	if instance, _ := instances.Find("i-2@db-01"); instance.InstanceId != "i-2" {
+		t.Errorf("Find should find i-2 instead of %q", instance.InstanceId)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// https://github.com/golang/crypto/blob/master/ssh/terminal/util.go
+
+var ioctlReadTermios uintptr
+var ioctlWriteTermios uintptr
+
+func init() {
+	if runtime.GOOS == "darwin" {
+		ioctlReadTermios = 0x40487413
+		ioctlWriteTermios = 0x80487414
+	} else {
+		ioctlReadTermios = 0x5401
+		ioctlWriteTermios = 0x5402
+	}
+}
+
+func getTermios(fd int) (termios syscall.Termios, ok bool) {
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), ioctlReadTermios, uintptr(unsafe.Pointer(&termios)), 0, 0, 0)
+	return termios, err == 0
+}
+
+func setTermios(fd int, termios syscall.Termios) bool {
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), ioctlWriteTermios, uintptr(unsafe.Pointer(&termios)), 0, 0, 0)
+	return err == 0
+}
+
+func isTerminal(fd int) bool {
+	_, ok := getTermios(fd)
+	return ok
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// Read a line from STDIN. It returns the error of ctx once ctx is done, like
+// on CTRL-C, instead of waiting for the line.
+func readLine(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	type result struct {
+		line string
+		err  error
+	}
+	results := make(chan result, 1)
+	reader := stdinReader
+	go func() {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			results <- result{"", err}
+			return
+		}
+		results <- result{strings.TrimRight(line, "\r\n"), nil}
+	}()
+	select {
+	case r := <-results:
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Read a line from STDIN without echoing it if STDIN is a terminal. Echo is
+// restored before it returns, also when it is interrupted.
+func readPassword(ctx context.Context, prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	fmt.Fprint(os.Stderr, prompt)
+	if termios, ok := getTermios(fd); ok {
+		noEcho := termios
+		noEcho.Lflag &^= syscall.ECHO
+		noEcho.Lflag |= syscall.ICANON | syscall.ISIG
+		setTermios(fd, noEcho)
+		defer func() {
+			setTermios(fd, termios)
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	return readLine(ctx)
+}
+
+// Ask a yes or no question, returns true only if answer begins with y or Y.
+// It exits if interrupted.
+func confirm(ctx context.Context, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := readLine(ctx)
+	if isInterrupted(err) {
+		fmt.Fprintln(os.Stderr)
+		exit(err)
+	}
+	if err != nil {
+		return false
+	}
+	return strings.IndexAny(answer, "Yy") == 0
+}
+
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+// Get number of columns of the terminal, or COLUMNS env var, or 80.
+func terminalWidth() int {
+	ioctlGetWinsize := uintptr(0x5413)
+	if runtime.GOOS == "darwin" {
+		ioctlGetWinsize = 0x40087468
+	}
+	var ws winsize
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, os.Stdout.Fd(), ioctlGetWinsize, uintptr(unsafe.Pointer(&ws)), 0, 0, 0)
+	if err == 0 && ws.Col > 0 {
+		return int(ws.Col)
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 80
+}
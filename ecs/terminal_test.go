@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadLineInterrupted(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	oldReader := stdinReader
+	stdinReader = bufio.NewReader(reader)
+	defer func() {
+		stdinReader = oldReader
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := readLine(ctx); err != context.Canceled {
+		t.Errorf("readLine should return when interrupted instead of %v", err)
+	}
+	if _, err := readLine(ctx); err != context.Canceled {
+		t.Errorf("readLine should not read after interrupted instead of %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha1"
@@ -11,12 +12,16 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/caiguanhao/aliyun/ecs/errors"
+	"github.com/caiguanhao/aliyun/redact"
+	"github.com/caiguanhao/aliyun/trace"
 	"github.com/codegangsta/cli"
 )
 
@@ -24,8 +29,21 @@ const TIME_FORMAT = "2006-01-02T15:04:05Z"
 const YMD_HMS_FORMAT = "2006-01-02 15:04:05"
 
 func exit(msg ...interface{}) {
-	fmt.Fprintln(os.Stderr, msg...)
-	os.Exit(1)
+	if len(msg) == 1 {
+		if err, ok := msg[0].(error); ok && isDryRunError(err) {
+			printError(err, "dry-run")
+			os.Exit(exitCode(err))
+		} else if ok {
+			printError(err, "error")
+			os.Exit(exitCode(err))
+		}
+	}
+	if IsJSONOutput {
+		printError(fmt.Errorf("%s", strings.TrimSuffix(fmt.Sprintln(msg...), "\n")), "error")
+	} else {
+		fmt.Fprintln(os.Stderr, msg...)
+	}
+	os.Exit(EXIT_ERROR)
 }
 
 func sign(secret string, query string) string {
@@ -64,15 +82,29 @@ func buildQueryString(input map[string]string) string {
 }
 
 func Request(url string, target interface{}) error {
+	return RequestContext(context.Background(), url, target)
+}
+
+func RequestContext(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	return sendRequest(ctx, req, target)
+}
+
+// Send request and decode JSON response into target, or the error.
+func sendRequest(ctx context.Context, req *http.Request, target interface{}) error {
 	if IsVerbose {
-		fmt.Println(url)
+		fmt.Println(req.Method, redact.URL(req.URL.String()))
 	}
 	client := http.Client{
-		Timeout: time.Duration(3 * time.Second),
+		Timeout:   time.Duration(3 * time.Second),
+		Transport: &trace.Transport{},
 	}
-	res, err := client.Get(url)
+	res, err := client.Do(req.WithContext(ctx))
 	if err != nil {
-		return err
+		return redact.Error(err)
 	}
 	defer res.Body.Close()
 	if IsVerbose {
@@ -131,52 +163,107 @@ func Request(url string, target interface{}) error {
 }
 
 func (ecs *ECS) Request(queries map[string]string, target interface{}) error {
-	params := map[string]string{
-		"Format":           "JSON",
-		"Version":          "2014-05-26",
-		"AccessKeyId":      ecs.KEY,
-		"SignatureMethod":  "HMAC-SHA1",
-		"SignatureVersion": "1.0",
-		"SignatureNonce":   randomString(64),
-		"Timestamp":        time.Now().UTC().Format(TIME_FORMAT),
-		"PageSize":         "50",
-		"PageNumber":       "1",
-	}
-	for k, v := range queries {
-		params[k] = v
-	}
-	query := buildQueryString(params)
-	signature := sign(ecs.SECRET, urlEncode(query))
-	url := fmt.Sprintf("http://ecs.aliyuncs.com/?%s&Signature=%s", query, urlEncode(signature))
-	return Request(url, target)
+	return ecs.RequestContext(ecs.Context(), queries, target)
+}
+
+func (ecs *ECS) RequestContext(ctx context.Context, queries map[string]string, target interface{}) error {
+	if IsDryRun && isMutatingAction(queries["Action"]) {
+		return ecs.dryRun(ctx, queries, target)
+	}
+	req, err := ecs.newRequest(queries)
+	if err == nil {
+		err = sendRequest(ctx, req, target)
+	}
+	err = withRequestContext(err, queries)
+	if isMutatingAction(queries["Action"]) {
+		recordRequest(queries, target, err)
+	}
+	return err
 }
 
+// Call do for each region in Regions, or all regions if Regions is empty.
+// Failures of some regions are printed as warnings and the results of the
+// other regions are kept; error is returned only if all regions fail.
 func ForAllRegionsDo(do func(region string) (err error)) (err error) {
-	var regions ECSRegions
+	regionIds := Regions
+	if len(regionIds) == 0 {
+		var regions ECSRegions
+		regions, _, err = ECS_INSTANCE.DescribeRegions()
+		if err != nil {
+			return
+		}
+		for _, region := range regions {
+			regionIds = append(regionIds, region.RegionID)
+		}
+	}
 	var wg sync.WaitGroup
+	var mutex sync.Mutex
 	var errs errors.Errors
-	regions, _, err = ECS_INSTANCE.DescribeRegions()
-	if err != nil {
-		return
-	}
-	for _, region := range regions {
+	for _, region := range regionIds {
 		wg.Add(1)
 		go func(region string) {
 			err := do(region)
 			if err != nil {
-				errs.Add(err.Error())
+				mutex.Lock()
+				errs.Add(withRequestContext(err, map[string]string{"RegionId": region}))
+				mutex.Unlock()
 			}
 			wg.Done()
-		}(region.RegionID)
+		}(region)
 	}
 	wg.Wait()
-	if errs.HaveError() {
+	if err = ECS_INSTANCE.Context().Err(); err != nil {
+		return
+	}
+	if len(errs.Errors) == len(regionIds) && errs.HaveError() {
 		err = errs.Errorify()
 		return
 	}
+	for _, err := range errs.Errors {
+		printError(err, "warning")
+	}
 	return
 }
 
+// Parse time in local time like 2006-01-02 15:04:05 or in RFC3339.
+func parseTime(input string) (t time.Time, err error) {
+	for _, layout := range []string{YMD_HMS_FORMAT, "2006-01-02 15:04", "2006-01-02"} {
+		t, err = time.ParseInLocation(layout, input, time.Local)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	t, err = time.Parse(time.RFC3339, input)
+	if err != nil {
+		err = fmt.Errorf("Invalid time %s, should be like %s.", input, YMD_HMS_FORMAT)
+	}
+	return t.UTC(), err
+}
+
+// Call check every few seconds until it returns true or an error, or until
+// timeout is reached or ctx is done.
+func waitFor(ctx context.Context, what string, timeout time.Duration, check func() (bool, error)) error {
+	fmt.Fprintf(os.Stderr, "Waiting for %s ...\n", what)
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for %s.", what)
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 type ECSInterface interface {
 	PrintTable()
 	Print()
@@ -198,16 +285,31 @@ func ForAllArgsDo(args []string, call func(arg string)) {
 func Print(printable ECSInterface, others ...interface{}) {
 	err := others[len(others)-1]
 	if err == nil {
-		if IsQuiet {
+		if IsJSONOutput {
+			pretty, err := json.MarshalIndent(printable, "", "  ")
+			if err != nil {
+				exit(err)
+			}
+			fmt.Printf("%s\n", pretty)
+		} else if IsQuiet {
 			printable.Print()
 		} else {
 			printable.PrintTable()
 		}
+	} else if err, ok := err.(error); ok && isDryRunError(err) {
+		printError(err, "dry-run")
 	} else {
 		exit(err)
 	}
 }
 
+var ansiEscapeRegexp = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Width of the string when displayed, without ANSI escape codes.
+func displayWidth(input string) int {
+	return utf8.RuneCountInString(ansiEscapeRegexp.ReplaceAllString(input, ""))
+}
+
 func PrintTable(
 	fields []interface{},
 	showFields bool,
@@ -216,9 +318,9 @@ func PrintTable(
 	getInfo func(i int) map[interface{}]interface{},
 ) {
 	fieldsLen := len(fields)
-	maxlengths := make([]interface{}, fieldsLen)
+	maxlengths := make([]int, fieldsLen)
 	for i, field := range fields {
-		maxlengths[i] = len(field.(string))
+		maxlengths[i] = displayWidth(field.(string))
 	}
 	var lines [][]interface{}
 	for i := 0; i < listLength; i++ {
@@ -229,20 +331,78 @@ func PrintTable(
 		line := make([]interface{}, fieldsLen)
 		for j, field := range fields {
 			line[j] = info[field]
-			l := len(line[j].(string))
-			if l > maxlengths[j].(int) {
+			l := displayWidth(line[j].(string))
+			if l > maxlengths[j] {
 				maxlengths[j] = l
 			}
 		}
 		lines = append(lines, line)
 	}
-	format := fmt.Sprintf(strings.TrimSpace(strings.Repeat("%%-%ds  ", fieldsLen))+"\n", maxlengths...)
+	printLine := func(line []interface{}) {
+		cells := make([]string, fieldsLen)
+		for j, cell := range line {
+			str := cell.(string)
+			cells[j] = str + strings.Repeat(" ", maxlengths[j]-displayWidth(str))
+		}
+		fmt.Println(strings.Join(cells, "  "))
+	}
 	if showFields {
-		fmt.Printf(format, fields...)
+		printLine(fields)
 	}
 	for _, line := range lines {
-		fmt.Printf(format, line...)
+		printLine(line)
+	}
+}
+
+func splitCommas(input []string) (output []string) {
+	for _, item := range input {
+		for _, part := range strings.Split(item, ",") {
+			part = strings.TrimSpace(part)
+			if part != "" {
+				output = append(output, part)
+			}
+		}
+	}
+	return
+}
+
+func fmtFloat(float float64, suffix string) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", float), "0"), ".") + suffix
+}
+
+func humanBytes(bytes float64) string {
+	const TB = 1 << 40
+	const GB = 1 << 30
+	const MB = 1 << 20
+	const KB = 1 << 10
+	abs := bytes
+	if bytes < 0 {
+		abs = bytes * -1
+	}
+	if abs >= TB {
+		return fmtFloat(bytes/TB, " TB")
+	}
+	if abs >= GB {
+		return fmtFloat(bytes/GB, " GB")
+	}
+	if abs >= MB {
+		return fmtFloat(bytes/MB, " MB")
+	}
+	if abs >= KB {
+		return fmtFloat(bytes/KB, " KB")
+	}
+	return fmt.Sprintf("%.0f bytes", bytes)
+}
+
+// Format value of a metric in unit: "%", "B/s" or "IOPS".
+func humanValue(value float64, unit string) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.0f%%", value)
+	case "B/s":
+		return strings.Replace(humanBytes(value), "bytes", "B", 1) + "/s"
 	}
+	return fmtFloat(value, " "+unit)
 }
 
 func printFlagsForCommand(c *cli.Context, name string) {
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testHumanValue(t *testing.T, value float64, unit, expected string) {
+	human := humanValue(value, unit)
+	if human != expected {
+		t.Errorf("human value error when value is %f %s: %s should be %s", value, unit, human, expected)
+	}
+}
+
+func TestHumanValue(t *testing.T) {
+	testHumanValue(t, 13, "%", "13%")
+	testHumanValue(t, 512, "B/s", "512 B/s")
+	testHumanValue(t, 1920, "B/s", "1.875 KB/s")
+	testHumanValue(t, 1024*1024*5, "B/s", "5 MB/s")
+	testHumanValue(t, 12.5, "IOPS", "12.5 IOPS")
+	testHumanValue(t, kbitsPerPeriod(600*8, 60), "B/s", "10 KB/s")
+}
+
+func TestWaitForInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	err := waitFor(ctx, "nothing", time.Minute, func() (bool, error) {
+		cancel()
+		return false, nil
+	})
+	if err != context.Canceled {
+		t.Errorf("error should be %v instead of %v", context.Canceled, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("waitFor should return once ctx is done")
+	}
+}
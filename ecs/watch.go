@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const highlightStart = "\033[7m"
+const highlightEnd = "\033[0m"
+
+// Flags of --watch and --interval of refresh, in seconds and defaults to
+// interval.
+func watchFlags(usage string, interval int) []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  "watch, w",
+			Usage: usage,
+		},
+		cli.IntFlag{
+			Name:  "interval, n",
+			Value: interval,
+			Usage: "refresh every N seconds with --watch",
+		},
+	}
+}
+
+// Get interval of refresh in seconds, 0 if not --watch.
+func watchInterval(c *cli.Context) int {
+	if !c.Bool("watch") {
+		return 0
+	}
+	if interval := c.Int("interval"); interval > 0 {
+		return interval
+	}
+	return 1
+}
+
+// Call refresh every interval seconds. If stdout is a terminal, screen is
+// cleared before each refresh so output is redrawn in place; otherwise
+// refresh should only append new lines.
+func watch(interval int, refresh func(inPlace bool)) {
+	inPlace := isTerminal(int(os.Stdout.Fd()))
+	for {
+		if inPlace {
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Every %ds: %s    %s\n\n", interval, strings.Join(os.Args, " "), time.Now().Format(YMD_HMS_FORMAT))
+		}
+		refresh(inPlace)
+		select {
+		case <-time.After(time.Duration(interval) * time.Second):
+		case <-ECS_INSTANCE.Context().Done():
+			os.Exit(EXIT_INTERRUPTED)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/codegangsta/cli"
+)
+
+func testWatchInterval(t *testing.T, args []string, expected int) {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range watchFlags("watch", 5) {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if actual := watchInterval(cli.NewContext(nil, set, nil)); actual != expected {
+		t.Errorf("interval of %v should be %d instead of %d", args, expected, actual)
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	testWatchInterval(t, nil, 0)
+	testWatchInterval(t, []string{"--interval", "10"}, 0)
+	testWatchInterval(t, []string{"--watch"}, 5)
+	testWatchInterval(t, []string{"--watch", "--interval", "10"}, 10)
+	testWatchInterval(t, []string{"--watch", "--interval", "0"}, 1)
+}
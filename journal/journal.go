@@ -0,0 +1,185 @@
+// Append-only local journal of operations that change resources, stored as
+// JSON lines in ~/.aliyun/journal.jsonl (or ALIYUN_JOURNAL, "off" to disable).
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/aliyun/redact"
+)
+
+type Entry struct {
+	Time      time.Time         `json:"Time"`
+	User      string            `json:"User"`
+	Tool      string            `json:"Tool"`
+	Profile   string            `json:"Profile,omitempty"`
+	Command   []string          `json:"Command"`
+	Action    string            `json:"Action"`
+	Params    map[string]string `json:"Params,omitempty"`
+	Targets   []string          `json:"Targets,omitempty"`
+	RequestId string            `json:"RequestId,omitempty"`
+	Result    string            `json:"Result"`
+	Error     string            `json:"Error,omitempty"`
+}
+
+type Entries []Entry
+
+// Parameters not recorded, user data may have secrets in scripts.
+var OMITTED_PARAMS = []string{"UserData"}
+
+var mutex sync.Mutex
+
+// Path of the journal file, empty if disabled.
+func File() string {
+	file := os.Getenv("ALIYUN_JOURNAL")
+	if file == "off" {
+		return ""
+	}
+	if file == "" {
+		file = filepath.Join(os.Getenv("HOME"), ".aliyun", "journal.jsonl")
+	}
+	return file
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Append entry to journal. Time, user, command and result are filled in if
+// empty and params are redacted or omitted.
+func Record(entry Entry) (err error) {
+	file := File()
+	if file == "" {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.User == "" {
+		entry.User = currentUser()
+	}
+	if entry.Command == nil {
+		entry.Command = redact.Args(os.Args)
+	}
+	if entry.Result == "" {
+		entry.Result = "ok"
+		if entry.Error != "" {
+			entry.Result = "error"
+		}
+	}
+	entry.Params = redact.Params(entry.Params)
+	for _, key := range OMITTED_PARAMS {
+		if value, ok := entry.Params[key]; ok {
+			entry.Params[key] = fmt.Sprintf("(%d bytes omitted)", len(value))
+		}
+	}
+	var line []byte
+	line, err = json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return
+	}
+	var f *os.File
+	f, err = os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return
+}
+
+// Record entry and print a warning if it can't be written.
+func RecordOrWarn(entry Entry) {
+	if err := Record(entry); err != nil {
+		fmt.Fprintln(os.Stderr, "[WARNING] journal:", err)
+	}
+}
+
+type Filter struct {
+	Since, Until time.Time
+	Tool         string
+	Action       string // case-insensitive substring of action
+	Target       string // substring of one of the targets
+}
+
+func (filter Filter) Match(entry Entry) bool {
+	if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && entry.Time.After(filter.Until) {
+		return false
+	}
+	if filter.Tool != "" && entry.Tool != filter.Tool {
+		return false
+	}
+	if filter.Action != "" && !strings.Contains(strings.ToLower(entry.Action), strings.ToLower(filter.Action)) {
+		return false
+	}
+	if filter.Target != "" {
+		for _, target := range entry.Targets {
+			if strings.Contains(target, filter.Target) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Read entries matching filter from journal file. Lines that can't be
+// parsed are skipped.
+func Read(file string, filter Filter) (entries Entries, err error) {
+	var f *os.File
+	f, err = os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Parse time like 2006-01-02 15:04:05 in local time, RFC3339, or duration
+// before now like 24h.
+func ParseTime(input string) (t time.Time, err error) {
+	if d, err := time.ParseDuration(input); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		t, err = time.ParseInLocation(layout, input, time.Local)
+		if err == nil {
+			return
+		}
+	}
+	return time.Parse(time.RFC3339, input)
+}
@@ -0,0 +1,55 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/aliyun/redact"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "journal.jsonl")
+	os.Setenv("ALIYUN_JOURNAL", file)
+	defer os.Unsetenv("ALIYUN_JOURNAL")
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	Record(Entry{Time: yesterday, Tool: "ecs", Action: "StopInstance", Targets: []string{"i-1"}})
+	Record(Entry{Tool: "ecs", Action: "CreateInstance", Params: map[string]string{"Password": "p4ss", "UserData": "IyEvYmluL3No"}, Targets: []string{"i-2"}, Error: "oops"})
+	Record(Entry{Tool: "oss", Action: "PUT", Targets: []string{"oss://bucket/a.txt"}})
+
+	testRead := func(filter Filter, expected ...string) {
+		entries, err := Read(file, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		for _, entry := range entries {
+			actions = append(actions, entry.Action)
+		}
+		if !reflect.DeepEqual(actions, expected) {
+			t.Errorf("Read(%+v) should get %v instead of %v", filter, expected, actions)
+		}
+	}
+	testRead(Filter{}, "StopInstance", "CreateInstance", "PUT")
+	testRead(Filter{Tool: "ecs"}, "StopInstance", "CreateInstance")
+	testRead(Filter{Since: time.Now().Add(-time.Hour)}, "CreateInstance", "PUT")
+	testRead(Filter{Action: "instance"}, "StopInstance", "CreateInstance")
+	testRead(Filter{Target: "a.txt"}, "PUT")
+
+	entries, _ := Read(file, Filter{Action: "CreateInstance"})
+	if entry := entries[0]; entry.Params["Password"] != redact.REDACTED || entry.Result != "error" {
+		t.Errorf("entry should be redacted and failed: %+v", entry)
+	}
+	if userData := entries[0].Params["UserData"]; userData != "(12 bytes omitted)" {
+		t.Errorf("user data should be omitted instead of %s", userData)
+	}
+}
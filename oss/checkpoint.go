@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// With --no-resume, existing checkpoints are ignored and failed multipart
+// uploads are aborted instead of being kept to resume.
+var noResume bool
+
+// Checkpoint of a multipart upload of local file to remote, saved after each
+// part is uploaded so that the upload can be resumed if the file is not
+// changed.
+type Checkpoint struct {
+	Bucket   string    `json:"bucket"`
+	Remote   string    `json:"remote"`
+	Local    string    `json:"local"`
+	Size     int64     `json:"size"`
+	ModTime  int64     `json:"mtime"`
+	PartSize int64     `json:"part_size"`
+	UploadId string    `json:"upload_id"`
+	Parts    []OSSPart `json:"parts"`
+	file     string
+}
+
+type ListPartsResult struct {
+	XMLName              xml.Name `xml:"ListPartsResult"`
+	IsTruncated          bool
+	NextPartNumberMarker int
+	Parts                []UploadedPart `xml:"Part"`
+}
+
+type UploadedPart struct {
+	PartNumber int
+	ETag       string
+	Size       int64
+}
+
+// Checkpoints are saved in ~/.aliyun/oss-checkpoints (or OSS_CHECKPOINT_DIR).
+func checkpointDir() string {
+	if dir := os.Getenv("OSS_CHECKPOINT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".aliyun", "oss-checkpoints")
+}
+
+// Get checkpoint of upload of local file to remote, which is empty if there
+// is none.
+func loadCheckpoint(local, remote string) (checkpoint *Checkpoint, err error) {
+	if local, err = filepath.Abs(local); err != nil {
+		return
+	}
+	name := fmt.Sprintf("%x.json", sha1.Sum([]byte(bucket+"\n"+remote+"\n"+local)))
+	checkpoint = &Checkpoint{Bucket: bucket, Remote: remote, Local: local, file: filepath.Join(checkpointDir(), name)}
+	content, err := ioutil.ReadFile(checkpoint.file)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, checkpoint); err != nil {
+		return nil, fmt.Errorf("%s: %s", checkpoint.file, err)
+	}
+	return
+}
+
+// Whether checkpoint has an upload of the same file of info.
+func (checkpoint *Checkpoint) Matches(info os.FileInfo) bool {
+	return checkpoint.UploadId != "" && checkpoint.PartSize > 0 && checkpoint.Size == info.Size() &&
+		checkpoint.ModTime == info.ModTime().UnixNano()
+}
+
+func (checkpoint *Checkpoint) Save() error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(checkpoint.file), 0700); err != nil {
+		return err
+	}
+	tmp := checkpoint.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, checkpoint.file)
+}
+
+func (checkpoint *Checkpoint) Remove() error {
+	err := os.Remove(checkpoint.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Set ETags of parts that are uploaded according to both the checkpoint and
+// the list of uploaded parts.
+func (checkpoint *Checkpoint) Restore(parts []OSSPart, uploaded []UploadedPart) {
+	etags := map[int]string{}
+	for _, part := range checkpoint.Parts {
+		etags[part.PartNumber] = part.ETag
+	}
+	for _, part := range uploaded {
+		i := part.PartNumber - 1
+		if i < 0 || i >= len(parts) || part.Size != parts[i].size {
+			continue
+		}
+		if etag := etags[part.PartNumber]; etag != "" && etag == part.ETag {
+			parts[i].ETag = etag
+		}
+	}
+}
+
+// Get all parts that are uploaded.
+func listParts(ctx context.Context, remote, uploadId string) (parts []UploadedPart, err error) {
+	marker := 0
+	for {
+		params := url.Values{"uploadId": {uploadId}}
+		if marker > 0 {
+			params.Set("part-number-marker", fmt.Sprint(marker))
+		}
+		var resp *http.Response
+		resp, err = sendGetRequest(ctx, multipartURI(remote, params))
+		if err != nil {
+			return
+		}
+		if err = checkOSSResponse(resp); err != nil {
+			return
+		}
+		var result ListPartsResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return
+		}
+		parts = append(parts, result.Parts...)
+		if !result.IsTruncated {
+			return
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
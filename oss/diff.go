@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/caiguanhao/gotogether"
@@ -38,6 +40,7 @@ var OSS_DIFF cli.Command = cli.Command{
 		checkMD5 := c.Bool("md5")
 
 		local, remote, localStrPos, remoteStrPos := parseArgsForOSSDiff([]string(c.Args()))
+		ctx := interruptContext()
 
 		var remoteFiles, localFiles []OSSFile
 		var localTimeUsed, remoteTimeUsed time.Duration
@@ -46,10 +49,13 @@ var OSS_DIFF cli.Command = cli.Command{
 			func() {
 				timeStart := time.Now()
 				var err error
-				remoteFiles, _, err = getOSSFileList(remote, true)
-				if err != nil {
+				remoteFiles, _, err = getOSSFileList(ctx, remote, true)
+				if err != nil && ctx.Err() == nil {
 					die(err)
 				}
+				if checkMD5 {
+					setMD5OfMultipartUploads(ctx, remoteFiles)
+				}
 				remoteTimeUsed = time.Since(timeStart)
 			},
 			func() {
@@ -58,12 +64,16 @@ var OSS_DIFF cli.Command = cli.Command{
 					fmt.Fprintf(os.Stderr, "MD5 checksum verification using up to %d goroutines\n", concurrency)
 				}
 				var errs int
-				localFiles, errs = getLocalFileList(local)
+				localFiles, errs = getLocalFileList(ctx, local)
 				totalErrors += errs
 				localTimeUsed = time.Since(timeStart)
 			},
 		}.Run()
 
+		if ctx.Err() != nil {
+			os.Exit(EXIT_INTERRUPTED)
+		}
+
 		localFilesLength, remoteFilesLength := len(localFiles), len(remoteFiles)
 
 		stdout, stderr := os.Stdout, os.Stderr
@@ -152,7 +162,8 @@ func parseArgsForOSSDiff(args []string) (local, remote string, localStrPos, remo
 	return
 }
 
-func getLocalFileList(local string) (files []OSSFile, errs int) {
+func getLocalFileList(ctx context.Context, local string) (files []OSSFile, errs int) {
+	var mutex sync.Mutex
 	gotogether.Queue{
 		Concurrency: concurrency,
 		AddJob: func(jobs *chan interface{}) {
@@ -163,20 +174,25 @@ func getLocalFileList(local string) (files []OSSFile, errs int) {
 				if !info.Mode().IsRegular() {
 					return nil
 				}
-				*jobs <- OSSFile{
-					Name: path,
-					Size: info.Size(),
+				select {
+				case *jobs <- OSSFile{Name: path, Size: info.Size()}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
 				}
-				return nil
 			})
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
 				debug(err)
+				mutex.Lock()
 				errs++
+				mutex.Unlock()
 			}
 		},
 		DoJob: func(job *interface{}) {
 			file := (*job).(OSSFile)
 			data, err := ioutil.ReadFile(file.Name)
+			mutex.Lock()
+			defer mutex.Unlock()
 			if err != nil {
 				file.err = err
 				errs++
@@ -189,6 +205,32 @@ func getLocalFileList(local string) (files []OSSFile, errs int) {
 	return
 }
 
+// ETag of multipart uploads like "<MD5>-3" is not MD5 of the content, it is
+// replaced with MD5 in MD5_META_HEADER if any.
+func setMD5OfMultipartUploads(ctx context.Context, files []OSSFile) {
+	gotogether.Queue{
+		Concurrency: concurrency,
+		AddJob: func(jobs *chan interface{}) {
+			for i := range files {
+				if !strings.Contains(files[i].ETag, "-") {
+					continue
+				}
+				select {
+				case *jobs <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		},
+		DoJob: func(job *interface{}) {
+			file := &files[(*job).(int)]
+			if md5sum := getRemoteMD5(ctx, "/"+file.Name); md5sum != "" {
+				file.ETag = fmt.Sprintf("\"%s\"", strings.ToUpper(md5sum))
+			}
+		},
+	}.Run()
+}
+
 func getDiff(localFiles, remoteFiles []OSSFile, localStrPos, remoteStrPos int, checkMD5 bool) (localOnly, remoteOnly []OSSFile) {
 	gotogether.Parallel{
 		func() {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -44,10 +45,15 @@ var OSS_DOWNLOAD cli.Command = cli.Command{
 			return
 		}
 
+		ctx := interruptContext()
+
 		defer func() {
 			if summary := stat.String(); summary != nil && !toStdout {
 				debug(*summary)
 			}
+			if ctx.Err() != nil {
+				os.Exit(EXIT_INTERRUPTED)
+			}
 			if totalErrors > 0 {
 				debugf("%d error(s) occurred during downloading.\n", totalErrors)
 				os.Exit(1)
@@ -57,8 +63,8 @@ var OSS_DOWNLOAD cli.Command = cli.Command{
 		}()
 
 		if toStdout {
-			ret, err := remoteFileToStdOut(remotes[0])
-			if err != nil {
+			ret, err := remoteFileToStdOut(ctx, remotes[0])
+			if err != nil && ctx.Err() == nil {
 				die(err)
 			}
 			stat.Add(ret)
@@ -70,14 +76,20 @@ var OSS_DOWNLOAD cli.Command = cli.Command{
 			AddJob: func(jobs *chan interface{}) {
 				for index, remote := range remotes {
 					paths := []string{remote, locals[index]}
-					*jobs <- paths
+					select {
+					case *jobs <- paths:
+					case <-ctx.Done():
+						return
+					}
 				}
 			},
 			DoJob: func(job *interface{}) {
 				paths := (*job).([]string)
-				size, err := remoteFileToLocalFile(paths[0], paths[1])
+				size, err := remoteFileToLocalFile(ctx, paths[0], paths[1])
 				if err == nil {
 					stat.Add(size)
+				} else if ctx.Err() != nil {
+					debug(paths[0]+":", "interrupted")
 				} else {
 					debug(paths[0], err)
 					totalErrors++
@@ -135,6 +147,10 @@ func parseArgsForOSSDownload(args []string) (remotes, locals []string) {
 }
 
 func getDownloadUrl(remote string, secondsFromNow int64) string {
+	if signatureVersion == SIGNATURE_V4 {
+		query := (&Signature{URI: remote}).presignV4(secondsFromNow)
+		return fmt.Sprintf("%s/%s?%s", api, url.QueryEscape(strings.TrimLeft(remote, "/")), query)
+	}
 	date := time.Now().Unix() + secondsFromNow
 	signature := (&Signature{Date: fmt.Sprintf("%d", date), URI: remote}).Get()
 	url := fmt.Sprintf("%s/%s?OSSAccessKeyId=%s&Expires=%d&Signature=%s",
@@ -142,8 +158,8 @@ func getDownloadUrl(remote string, secondsFromNow int64) string {
 	return url
 }
 
-func getRemoteFile(remote string) (resp *http.Response, err error) {
-	resp, err = sendGetRequest(remote)
+func getRemoteFile(ctx context.Context, remote string) (resp *http.Response, err error) {
+	resp, err = sendGetRequest(ctx, remote)
 	if err != nil {
 		return
 	}
@@ -151,9 +167,9 @@ func getRemoteFile(remote string) (resp *http.Response, err error) {
 	return
 }
 
-func remoteFileToStdOut(remote string) (written int64, err error) {
+func remoteFileToStdOut(ctx context.Context, remote string) (written int64, err error) {
 	var resp *http.Response
-	resp, err = getRemoteFile(remote)
+	resp, err = getRemoteFile(ctx, remote)
 	if err != nil {
 		return
 	}
@@ -164,21 +180,29 @@ func remoteFileToStdOut(remote string) (written int64, err error) {
 	return
 }
 
-func remoteFileToLocalFile(remote, local string) (written int64, err error) {
+// Half-written local file is removed if download fails or is interrupted.
+func remoteFileToLocalFile(ctx context.Context, remote, local string) (written int64, err error) {
 	var resp *http.Response
-	resp, err = getRemoteFile(remote)
+	resp, err = getRemoteFile(ctx, remote)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 	var file *os.File
 	file, err = os.Create(local)
-	defer file.Close()
-	if err == nil {
-		debugf("Downloading %s to %s ...\n", remote, local)
-		written, err = io.Copy(file, resp.Body)
-		debugf("Downloaded %s to %s ...\n", remote, local)
+	if err != nil {
+		return
+	}
+	debugf("Downloading %s to %s ...\n", remote, local)
+	written, err = io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(local)
+		return
 	}
+	debugf("Downloaded %s to %s ...\n", remote, local)
 	return
 }
 
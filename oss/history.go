@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiguanhao/aliyun/journal"
+	"github.com/codegangsta/cli"
+)
+
+var OSS_HISTORY cli.Command = cli.Command{
+	Name:      "history",
+	Usage:     "show journal of uploads and other changes of remote files",
+	ArgsUsage: " ",
+	Description: `Every request other than GET and HEAD is recorded in ~/.aliyun/journal.jsonl,
+   or the file in ALIYUN_JOURNAL environment variable (set to "off" to disable).
+   --since and --until can be time like 2006-01-02 15:04 or duration like 24h.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "since, s",
+			Usage: "show requests since the time",
+		},
+		cli.StringFlag{
+			Name:  "until, u",
+			Usage: "show requests until the time",
+		},
+		cli.StringFlag{
+			Name:  "action, a",
+			Usage: "show requests of the method, like PUT",
+		},
+		cli.StringFlag{
+			Name:  "target, t",
+			Usage: "show requests of remote files containing the text",
+		},
+	},
+	Action: func(c *cli.Context) {
+		filter := journal.Filter{
+			Tool:   "oss",
+			Action: c.String("action"),
+			Target: c.String("target"),
+		}
+		var err error
+		if c.IsSet("since") {
+			if filter.Since, err = journal.ParseTime(c.String("since")); err != nil {
+				die(err)
+			}
+		}
+		if c.IsSet("until") {
+			if filter.Until, err = journal.ParseTime(c.String("until")); err != nil {
+				die(err)
+			}
+		}
+		entries, err := journal.Read(journal.File(), filter)
+		if err != nil {
+			die(err)
+		}
+		for _, entry := range entries {
+			result := entry.Result
+			if entry.Error != "" {
+				result += ": " + entry.Error
+			}
+			fmt.Printf("%s  %s  %s  %s  %s  %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"),
+				entry.User, entry.Action, strings.Join(entry.Targets, ", "), result, entry.RequestId)
+		}
+	},
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
@@ -49,6 +50,7 @@ var OSS_LIST cli.Command = cli.Command{
 	},
 	Action: func(c *cli.Context) {
 		remotes := parseArgsForOSSList([]string(c.Args()))
+		ctx := interruptContext()
 		multiple := len(remotes) > 1
 		for i, remote := range remotes {
 			if multiple {
@@ -57,7 +59,10 @@ var OSS_LIST cli.Command = cli.Command{
 				}
 				fmt.Printf("%s:\n", remote)
 			}
-			remoteFiles, remoteDirs, err := getOSSFileList(remote, c.Bool("recursive"))
+			remoteFiles, remoteDirs, err := getOSSFileList(ctx, remote, c.Bool("recursive"))
+			if ctx.Err() != nil {
+				os.Exit(EXIT_INTERRUPTED)
+			}
 			if err != nil {
 				die(err)
 			}
@@ -96,7 +101,7 @@ func parseArgsForOSSList(args []string) (remotes []string) {
 	return
 }
 
-func getOSSFileListWithMarker(prefix string, marker *string, files *[]OSSFile, dirs *[]OSSDirectory, recursive bool) (err error) {
+func getOSSFileListWithMarker(ctx context.Context, prefix string, marker *string, files *[]OSSFile, dirs *[]OSSDirectory, recursive bool) (err error) {
 	queryString := "?max-keys=1000"
 	if !recursive {
 		queryString += "&delimiter=/"
@@ -106,7 +111,7 @@ func getOSSFileListWithMarker(prefix string, marker *string, files *[]OSSFile, d
 		queryString += "&marker=" + url.QueryEscape(*marker)
 	}
 	var resp *http.Response
-	resp, err = sendGetRequest("/" + queryString)
+	resp, err = sendGetRequest(ctx, "/"+queryString)
 	if err != nil {
 		return
 	}
@@ -125,12 +130,12 @@ func getOSSFileListWithMarker(prefix string, marker *string, files *[]OSSFile, d
 		fmt.Fprintf(os.Stderr, "Remote: received %d file names (out of %d) ...\n", len(list.Files), len(*files))
 	}
 	if list.IsTruncated {
-		err = getOSSFileListWithMarker(prefix, &list.NextMarker, files, dirs, recursive)
+		err = getOSSFileListWithMarker(ctx, prefix, &list.NextMarker, files, dirs, recursive)
 	}
 	return
 }
 
-func getOSSFileList(prefix string, recursive bool) (files []OSSFile, dirs []OSSDirectory, err error) {
-	err = getOSSFileListWithMarker(prefix, nil, &files, &dirs, recursive)
+func getOSSFileList(ctx context.Context, prefix string, recursive bool) (files []OSSFile, dirs []OSSDirectory, err error) {
+	err = getOSSFileListWithMarker(ctx, prefix, nil, &files, &dirs, recursive)
 	return
 }
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/aliyun/trace"
+	"github.com/caiguanhao/gotogether"
+)
+
+const MB = 1 << 20
+
+// OSS allows no more than 10000 parts of no more than 5 GB and parts except
+// the last one must be at least 100 KB.
+const MAX_PARTS = 10000
+const MIN_PART_SIZE = 100 << 10
+const MAX_PART_SIZE = 5 << 30
+
+// Header of hex MD5 of the whole file of multipart uploads, since their ETag
+// is not MD5 of the content.
+const MD5_META_HEADER = "X-Oss-Meta-Md5"
+
+// Number of attempts to upload each part, failed requests are retried after
+// partRetryDelay, doubled after each attempt.
+const PART_ATTEMPTS = 3
+
+var partRetryDelay = time.Second
+
+// Files not smaller than multipartThreshold are uploaded in parts of
+// partSize, partConcurrency parts at a time. Parts of all files being
+// uploaded share partBuffers, so no more than partSize * partConcurrency of
+// memory is used however many files are uploaded together.
+var partSize int64 = 8 * MB
+var multipartThreshold int64 = 64 * MB
+var partConcurrency = 4
+var partBuffers = make(chan bool, partConcurrency)
+
+func setPartConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	partConcurrency = n
+	partBuffers = make(chan bool, n)
+}
+
+type InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string
+	Key      string
+	UploadId string
+}
+
+type CompleteMultipartUpload struct {
+	XMLName xml.Name  `xml:"CompleteMultipartUpload"`
+	Parts   []OSSPart `xml:"Part"`
+}
+
+type OSSPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	offset     int64
+	size       int64
+}
+
+// Get size of each part of file of size, large enough to have no more than
+// MAX_PARTS parts.
+func partSizeOf(size, partSize int64) int64 {
+	if partSize < MIN_PART_SIZE {
+		partSize = MIN_PART_SIZE
+	}
+	if partSize > MAX_PART_SIZE {
+		partSize = MAX_PART_SIZE
+	}
+	if min := (size + MAX_PARTS - 1) / MAX_PARTS; partSize < min {
+		partSize = min
+	}
+	return partSize
+}
+
+func multipartURI(remote string, params url.Values) string {
+	return remote + "?" + params.Encode()
+}
+
+// Initiate multipart upload of file of hex MD5 md5sum, if not empty.
+func initiateMultipartUpload(ctx context.Context, remote, md5sum string) (uploadId string, err error) {
+	headers := http.Header{}
+	if contentType := mime.TypeByExtension(filepath.Ext(remote)); contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+	if md5sum != "" {
+		headers.Set(MD5_META_HEADER, md5sum)
+	}
+	var resp *http.Response
+	resp, err = sendRequestWithHeaders(ctx, "POST", remote+"?uploads", nil, nil, headers)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if err = checkOSSResponse(resp); err != nil {
+		return
+	}
+	var result InitiateMultipartUploadResult
+	if err = xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return
+	}
+	uploadId = result.UploadId
+	return
+}
+
+// Upload part of number. Requests failed of network errors or 5xx responses
+// are retried unless ctx is done, other errors like 403 are returned.
+func uploadPart(ctx context.Context, remote, uploadId string, number int, data []byte) (etag string, err error) {
+	uri := multipartURI(remote, url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadId}})
+	delay := partRetryDelay
+	for attempt := 1; attempt <= PART_ATTEMPTS; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(delay):
+				delay *= 2
+			case <-ctx.Done():
+				return
+			}
+		}
+		var resp *http.Response
+		resp, err = sendRequest(trace.WithAttempt(ctx, attempt), "PUT", uri, data, nil)
+		if err == nil {
+			err = checkOSSResponse(resp)
+			if err == nil {
+				etag = resp.Header.Get("ETag")
+				resp.Body.Close()
+				return
+			}
+			if resp.StatusCode < 500 {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	return
+}
+
+func completeMultipartUpload(ctx context.Context, remote, uploadId string, parts []OSSPart) (resp *http.Response, err error) {
+	var body []byte
+	body, err = xml.Marshal(CompleteMultipartUpload{Parts: parts})
+	if err != nil {
+		return
+	}
+	resp, err = sendRequest(ctx, "POST", multipartURI(remote, url.Values{"uploadId": {uploadId}}), body, nil)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	err = checkOSSResponse(resp)
+	return
+}
+
+// Abort is sent even if ctx is cancelled so that uploaded parts are removed.
+func abortMultipartUpload(remote, uploadId string) error {
+	resp, err := sendRequest(context.Background(), "DELETE", multipartURI(remote, url.Values{"uploadId": {uploadId}}), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 204 {
+		return checkOSSResponse(resp)
+	}
+	return nil
+}
+
+// Split file of size into parts of partSize.
+func newParts(size, partSize int64) (parts []OSSPart) {
+	for offset := int64(0); offset < size; offset += partSize {
+		part := OSSPart{PartNumber: len(parts) + 1, offset: offset, size: partSize}
+		if offset+partSize > size {
+			part.size = size - offset
+		}
+		parts = append(parts, part)
+	}
+	return
+}
+
+// Upload parts of file that have no ETag, partConcurrency parts at a time.
+// Each part is read from file when one of partBuffers is free, so that no
+// more than partConcurrency parts of all files are in memory. Remaining parts
+// are cancelled if any part fails. Function done is called after each part is
+// uploaded.
+func uploadParts(ctx context.Context, file io.ReaderAt, remote, uploadId string, parts []OSSPart, done func(OSSPart)) error {
+	partsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mutex sync.Mutex
+	var firstErr error
+	gotogether.Queue{
+		Concurrency: partConcurrency,
+		AddJob: func(jobs *chan interface{}) {
+			for i := range parts {
+				if parts[i].ETag != "" {
+					continue
+				}
+				select {
+				case *jobs <- i:
+				case <-partsCtx.Done():
+					return
+				}
+			}
+		},
+		DoJob: func(job *interface{}) {
+			part := &parts[(*job).(int)]
+			err := uploadPartOfFile(partsCtx, file, remote, uploadId, part)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if firstErr == nil && partsCtx.Err() == nil {
+					firstErr = fmt.Errorf("part %d: %s", part.PartNumber, err)
+					cancel()
+				}
+				return
+			}
+			if verbose {
+				debugf("%s: uploaded part %d of %d\n", remote, part.PartNumber, len(parts))
+			}
+			if done != nil {
+				done(*part)
+			}
+		},
+	}.Run()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
+
+// Read part from file and upload it, waiting for one of partBuffers.
+func uploadPartOfFile(ctx context.Context, file io.ReaderAt, remote, uploadId string, part *OSSPart) (err error) {
+	buffers := partBuffers
+	select {
+	case buffers <- true:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-buffers }()
+	data := make([]byte, part.size)
+	n, err := file.ReadAt(data, part.offset)
+	if err == io.EOF && int64(n) == part.size {
+		err = nil
+	}
+	if err == nil {
+		part.ETag, err = uploadPart(ctx, remote, uploadId, part.PartNumber, data)
+	}
+	return
+}
+
+// Upload local file of info and hex MD5 md5sum in parts. Unless noResume, a
+// checkpoint is saved after each part and the upload is resumed next time if
+// the file is not changed. With noResume, the upload is aborted if any part
+// fails.
+func localFileToRemoteFileMultipart(ctx context.Context, local, remote string, info os.FileInfo, md5sum string) (written int64, err error) {
+	var file *os.File
+	file, err = os.Open(local)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var checkpoint *Checkpoint
+	checkpoint, err = loadCheckpoint(local, remote)
+	if err != nil {
+		return
+	}
+	size := info.Size()
+	var parts []OSSPart
+	var uploadId string
+	if !noResume && checkpoint.Matches(info) {
+		var uploaded []UploadedPart
+		uploaded, err = listParts(ctx, remote, checkpoint.UploadId)
+		if err == nil {
+			uploadId = checkpoint.UploadId
+			parts = newParts(size, checkpoint.PartSize)
+			checkpoint.Restore(parts, uploaded)
+		} else if ctx.Err() != nil {
+			return
+		} else {
+			debug(remote+":", "cannot resume upload:", err)
+			err = nil
+		}
+	}
+	if uploadId == "" {
+		if checkpoint.UploadId != "" {
+			// previous upload of changed file or with --no-resume is not needed
+			abortMultipartUpload(remote, checkpoint.UploadId)
+			checkpoint.Remove()
+		}
+		uploadId, err = initiateMultipartUpload(ctx, remote, md5sum)
+		if err != nil {
+			return
+		}
+		checkpoint.UploadId = uploadId
+		checkpoint.Size = size
+		checkpoint.ModTime = info.ModTime().UnixNano()
+		checkpoint.PartSize = partSizeOf(size, partSize)
+		checkpoint.Parts = nil
+		parts = newParts(size, checkpoint.PartSize)
+		if !noResume {
+			if err = checkpoint.Save(); err != nil {
+				abortMultipartUpload(remote, uploadId)
+				return
+			}
+		}
+	}
+	var resp *http.Response
+	defer func() {
+		if err != nil && noResume {
+			if abortErr := abortMultipartUpload(remote, uploadId); abortErr != nil {
+				debug(remote+":", "failed to abort multipart upload", uploadId+":", abortErr)
+			}
+		} else if err != nil {
+			debug(remote+":", "upload is saved to", checkpoint.file+", run again to resume")
+		} else {
+			checkpoint.Remove()
+		}
+		recordRequest("PUT", remote, size, resp, err)
+	}()
+
+	// restored parts replace those of the loaded checkpoint
+	checkpoint.Parts = nil
+	var resumed int
+	for _, part := range parts {
+		if part.ETag != "" {
+			checkpoint.Parts = append(checkpoint.Parts, part)
+			resumed++
+		}
+	}
+	if resumed > 0 {
+		fmt.Println(remote+":", "resuming upload,", resumed, "of", len(parts), "parts uploaded")
+	} else {
+		fmt.Println(remote+":", "uploading in", len(parts), "parts")
+	}
+	// called with lock of uploadParts held
+	done := func(part OSSPart) {
+		written += part.size
+		if noResume {
+			return
+		}
+		checkpoint.Parts = append(checkpoint.Parts, part)
+		if err := checkpoint.Save(); err != nil {
+			debug(remote+":", "failed to save checkpoint:", err)
+		}
+	}
+	if err = uploadParts(ctx, file, remote, uploadId, parts, done); err != nil {
+		return
+	}
+	resp, err = completeMultipartUpload(ctx, remote, uploadId, parts)
+	if err != nil {
+		return
+	}
+	fmt.Println(remote+":", "done")
+	return
+}
+
+// Upload contents of r in parts of partSize until EOF, partConcurrency parts
+// at a time. Since r can't be read again, the upload is not saved to resume
+// but aborted if any part fails.
+func readerToRemoteFileMultipart(ctx context.Context, r io.Reader, remote string) (written int64, err error) {
+	size := partSizeOf(0, partSize)
+	var uploadId string
+	uploadId, err = initiateMultipartUpload(ctx, remote, "")
+	if err != nil {
+		return
+	}
+	var resp *http.Response
+	defer func() {
+		if err != nil {
+			if abortErr := abortMultipartUpload(remote, uploadId); abortErr != nil {
+				debug(remote+":", "failed to abort multipart upload", uploadId+":", abortErr)
+			}
+		}
+		recordRequest("PUT", remote, written, resp, err)
+	}()
+	fmt.Println(remote+":", "uploading in parts")
+
+	partsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var parts []OSSPart
+	var firstErr error
+	setErr := func(err error) {
+		if firstErr == nil && partsCtx.Err() == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+read:
+	for number := 1; ; number++ {
+		buffers := partBuffers
+		select {
+		case buffers <- true:
+		case <-partsCtx.Done():
+			break read
+		}
+		data := make([]byte, size)
+		n, readErr := io.ReadFull(r, data)
+		if readErr == io.EOF {
+			<-buffers
+			break
+		}
+		last := readErr == io.ErrUnexpectedEOF
+		if last {
+			readErr = nil
+		}
+		if readErr == nil && number > MAX_PARTS {
+			readErr = fmt.Errorf("more than %d parts, use larger --part-size", MAX_PARTS)
+		}
+		if readErr != nil {
+			<-buffers
+			mutex.Lock()
+			setErr(readErr)
+			mutex.Unlock()
+			break
+		}
+		mutex.Lock()
+		parts = append(parts, OSSPart{PartNumber: number, size: int64(n)})
+		mutex.Unlock()
+		wg.Add(1)
+		go func(number int, data []byte) {
+			defer wg.Done()
+			defer func() { <-buffers }()
+			etag, err := uploadPart(partsCtx, remote, uploadId, number, data)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				setErr(fmt.Errorf("part %d: %s", number, err))
+				return
+			}
+			parts[number-1].ETag = etag
+			written += int64(len(data))
+			if verbose {
+				debugf("%s: uploaded part %d\n", remote, number)
+			}
+		}(number, data[:n])
+		if last {
+			break
+		}
+	}
+	wg.Wait()
+	if err = firstErr; err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return
+	}
+	resp, err = completeMultipartUpload(ctx, remote, uploadId, parts)
+	if err != nil {
+		return
+	}
+	fmt.Println(remote+":", "done")
+	return
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fake OSS server of multipart uploads of one object.
+type multipartServer struct {
+	sync.Mutex
+	parts      map[int][]byte
+	object     []byte
+	aborted    bool
+	running    int
+	maxRunning int
+	failPart   int
+	failStatus int
+	attempts   int
+	uploads    int
+	md5sum     string
+}
+
+func (s *multipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case r.Method == "HEAD":
+		if s.object == nil {
+			w.WriteHeader(404)
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"%X-%d"`, md5.Sum(s.object), len(s.parts)))
+		w.Header().Set(MD5_META_HEADER, s.md5sum)
+	case r.Method == "POST" && query["uploads"] != nil:
+		s.md5sum = r.Header.Get(MD5_META_HEADER)
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == "PUT" && query.Get("uploadId") == "upload-1":
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		s.Lock()
+		s.running++
+		if s.running > s.maxRunning {
+			s.maxRunning = s.running
+		}
+		s.Unlock()
+		defer func() {
+			s.Lock()
+			s.running--
+			s.Unlock()
+		}()
+		if number == s.failPart {
+			s.Lock()
+			s.attempts++
+			s.Unlock()
+			if s.failStatus == 0 {
+				s.failStatus = 500
+			}
+			w.WriteHeader(s.failStatus)
+			fmt.Fprint(w, "<Error><Message>failed</Message></Error>")
+			return
+		}
+		s.Lock()
+		s.parts[number] = body
+		s.uploads++
+		s.Unlock()
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == "GET" && query.Get("uploadId") == "upload-1":
+		var result ListPartsResult
+		for number, part := range s.parts {
+			etag := fmt.Sprintf(`"etag-%d"`, number)
+			result.Parts = append(result.Parts, UploadedPart{PartNumber: number, ETag: etag, Size: int64(len(part))})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == "POST" && query.Get("uploadId") == "upload-1":
+		var complete CompleteMultipartUpload
+		xml.Unmarshal(body, &complete)
+		var numbers []int
+		for _, part := range complete.Parts {
+			if part.ETag != fmt.Sprintf(`"etag-%d"`, part.PartNumber) {
+				w.WriteHeader(400)
+				return
+			}
+			numbers = append(numbers, part.PartNumber)
+		}
+		sort.Ints(numbers)
+		for _, number := range numbers {
+			s.object = append(s.object, s.parts[number]...)
+		}
+	case r.Method == "DELETE" && query.Get("uploadId") == "upload-1":
+		s.aborted = true
+		w.WriteHeader(204)
+	default:
+		w.WriteHeader(400)
+	}
+}
+
+func withMultipartServer(t *testing.T, failPart int, fn func(server *multipartServer, local string, info os.FileInfo)) {
+	os.Setenv("ALIYUN_JOURNAL", "off")
+	server := &multipartServer{parts: map[int][]byte{}, failPart: failPart}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	oldAPI, oldPartSize, oldConcurrency := api, partSize, partConcurrency
+	api, partSize = ts.URL, MIN_PART_SIZE
+	oldDelay := partRetryDelay
+	partRetryDelay = time.Millisecond
+	setPartConcurrency(2)
+	defer func() {
+		api, partSize, partRetryDelay = oldAPI, oldPartSize, oldDelay
+		setPartConcurrency(oldConcurrency)
+	}()
+	dir, err := ioutil.TempDir("", "oss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("OSS_CHECKPOINT_DIR", filepath.Join(dir, "checkpoints"))
+	defer os.Unsetenv("OSS_CHECKPOINT_DIR")
+	local := filepath.Join(dir, "file")
+	content := bytes.Repeat([]byte("0123456789"), MIN_PART_SIZE/2)
+	if err := ioutil.WriteFile(local, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn(server, local, info)
+}
+
+func TestPartSizeOf(t *testing.T) {
+	if actual := partSizeOf(100*MB, 8*MB); actual != 8*MB {
+		t.Errorf("part size should be %d instead of %d", 8*MB, actual)
+	}
+	if actual := partSizeOf(100*MB, 1); actual != MIN_PART_SIZE {
+		t.Errorf("part size should be %d instead of %d", MIN_PART_SIZE, actual)
+	}
+	if actual := partSizeOf(100*MB, 6<<30); actual != MAX_PART_SIZE {
+		t.Errorf("part size should be %d instead of %d", MAX_PART_SIZE, actual)
+	}
+	if actual := partSizeOf(1<<40, 8*MB); actual*MAX_PARTS < 1<<40 {
+		t.Errorf("part size %d makes more than %d parts", actual, MAX_PARTS)
+	}
+}
+
+func TestNewParts(t *testing.T) {
+	parts := newParts(250, 100)
+	if len(parts) != 3 {
+		t.Fatalf("there should be 3 parts instead of %d", len(parts))
+	}
+	last := parts[2]
+	if last.PartNumber != 3 || last.offset != 200 || last.size != 50 {
+		t.Errorf("last part is wrong: %+v", last)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	withMultipartServer(t, 0, func(server *multipartServer, local string, info os.FileInfo) {
+		written, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != info.Size() {
+			t.Errorf("written should be %d instead of %d", info.Size(), written)
+		}
+		content, _ := ioutil.ReadFile(local)
+		if !bytes.Equal(server.object, content) {
+			t.Error("uploaded object is different from local file")
+		}
+		if len(server.parts) != 5 {
+			t.Errorf("there should be 5 parts instead of %d", len(server.parts))
+		}
+		if server.maxRunning > partConcurrency {
+			t.Errorf("no more than %d parts should be uploaded together instead of %d", partConcurrency, server.maxRunning)
+		}
+	})
+}
+
+func TestMultipartUploadUnchanged(t *testing.T) {
+	withMultipartServer(t, 0, func(server *multipartServer, local string, info os.FileInfo) {
+		oldThreshold := multipartThreshold
+		multipartThreshold = MIN_PART_SIZE
+		defer func() {
+			multipartThreshold = oldThreshold
+		}()
+		if _, err := localFileToRemoteFile(context.Background(), local, "/file"); err != nil {
+			t.Fatal(err)
+		}
+		content, _ := ioutil.ReadFile(local)
+		if expected := fmt.Sprintf("%x", md5.Sum(content)); server.md5sum != expected {
+			t.Errorf("MD5 of upload should be %s instead of %s", expected, server.md5sum)
+		}
+		server.uploads = 0
+		written, err := localFileToRemoteFile(context.Background(), local, "/file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != 0 || server.uploads != 0 {
+			t.Errorf("unchanged file should not be uploaded again, %d bytes of %d parts uploaded", written, server.uploads)
+		}
+	})
+}
+
+func TestMultipartUploadReader(t *testing.T) {
+	for _, failPart := range []int{0, 3} {
+		withMultipartServer(t, failPart, func(server *multipartServer, local string, info os.FileInfo) {
+			oldThreshold := multipartThreshold
+			multipartThreshold = MIN_PART_SIZE
+			defer func() {
+				multipartThreshold = oldThreshold
+			}()
+			content, _ := ioutil.ReadFile(local)
+			// not a file so it is read once
+			r := ioutil.NopCloser(bytes.NewReader(content))
+			written, err := localReaderToRemoteFile(context.Background(), r, "/file")
+			if failPart > 0 {
+				if err == nil || !server.aborted {
+					t.Errorf("upload should fail and be aborted instead of %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if written != int64(len(content)) || !bytes.Equal(server.object, content) {
+				t.Error("uploaded object is different from input")
+			}
+			if len(server.parts) != 5 {
+				t.Errorf("there should be 5 parts instead of %d", len(server.parts))
+			}
+		})
+	}
+}
+
+func TestMultipartUploadSharedBuffers(t *testing.T) {
+	withMultipartServer(t, 0, func(server *multipartServer, local string, info os.FileInfo) {
+		// a part of another file is in memory
+		partBuffers <- true
+		defer func() { <-partBuffers }()
+		if _, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, ""); err != nil {
+			t.Fatal(err)
+		}
+		if server.maxRunning != 1 {
+			t.Errorf("one part should be uploaded at a time instead of %d", server.maxRunning)
+		}
+	})
+}
+
+func TestMultipartUploadAbort(t *testing.T) {
+	noResume = true
+	defer func() {
+		noResume = false
+	}()
+	withMultipartServer(t, 2, func(server *multipartServer, local string, info os.FileInfo) {
+		_, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, "")
+		if err == nil {
+			t.Fatal("upload should fail")
+		}
+		if !server.aborted {
+			t.Error("upload should be aborted")
+		}
+		if server.object != nil {
+			t.Error("upload should not be completed")
+		}
+	})
+}
+
+func TestUploadPartRetry(t *testing.T) {
+	withMultipartServer(t, 1, func(server *multipartServer, local string, info os.FileInfo) {
+		if _, err := uploadPart(context.Background(), "/file", "upload-1", 1, []byte("data")); err == nil {
+			t.Fatal("upload should fail")
+		}
+		if server.attempts != PART_ATTEMPTS {
+			t.Errorf("part should be uploaded %d times instead of %d", PART_ATTEMPTS, server.attempts)
+		}
+		server.attempts, server.failStatus = 0, 403
+		if _, err := uploadPart(context.Background(), "/file", "upload-1", 1, []byte("data")); err == nil {
+			t.Fatal("upload should fail")
+		}
+		if server.attempts != 1 {
+			t.Errorf("part should not be retried after 403 but uploaded %d times", server.attempts)
+		}
+	})
+}
+
+func TestMultipartUploadResume(t *testing.T) {
+	withMultipartServer(t, 2, func(server *multipartServer, local string, info os.FileInfo) {
+		// one part at a time so no request is running after the failure
+		setPartConcurrency(1)
+		if _, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, ""); err == nil {
+			t.Fatal("upload should fail")
+		}
+		if server.aborted {
+			t.Error("upload should be kept to resume")
+		}
+		checkpoint, err := loadCheckpoint(local, "/file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !checkpoint.Matches(info) || len(checkpoint.Parts) != 1 || checkpoint.Parts[0].PartNumber != 1 {
+			t.Fatalf("checkpoint should have part 1: %+v", checkpoint)
+		}
+
+		// resume but fail again
+		server.failPart = 4
+		if _, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, ""); err == nil {
+			t.Fatal("upload should fail")
+		}
+		checkpoint, err = loadCheckpoint(local, "/file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checkpoint.Parts) != 3 {
+			t.Fatalf("checkpoint should have parts 1 to 3 once: %+v", checkpoint.Parts)
+		}
+
+		server.failPart = 0
+		server.uploads = 0
+		uploaded := len(checkpoint.Parts)
+		written, err := localFileToRemoteFileMultipart(context.Background(), local, "/file", info, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.uploads != 5-uploaded {
+			t.Errorf("%d parts should be uploaded instead of %d", 5-uploaded, server.uploads)
+		}
+		if written != info.Size()-int64(uploaded)*MIN_PART_SIZE {
+			t.Errorf("written %d should not include resumed parts", written)
+		}
+		content, _ := ioutil.ReadFile(local)
+		if !bytes.Equal(server.object, content) {
+			t.Error("uploaded object is different from local file")
+		}
+		if _, err := os.Stat(checkpoint.file); !os.IsNotExist(err) {
+			t.Error("checkpoint should be removed")
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/caiguanhao/aliyun/trace"
 	"github.com/codegangsta/cli"
 )
 
@@ -35,6 +36,7 @@ func main() {
 		OSS_DOWNLOAD,
 		OSS_LIST,
 		OSS_DIFF,
+		OSS_HISTORY,
 	}
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
@@ -78,6 +80,27 @@ func main() {
 			Usage:       "show more info",
 			Destination: &verbose,
 		},
+		cli.BoolFlag{
+			Name:  "debug",
+			Usage: "log requests and responses with secrets masked",
+		},
+		cli.StringFlag{
+			Name:  "log-file",
+			Usage: "write --debug log to file instead of stderr, implies --debug",
+		},
+		cli.StringFlag{
+			Name:        "signature-version",
+			Value:       SIGNATURE_V1,
+			Usage:       "v1 (HMAC-SHA1) or v4 (OSS4-HMAC-SHA256)",
+			EnvVar:      "OSS_SIGNATURE_VERSION",
+			Destination: &signatureVersion,
+		},
+		cli.StringFlag{
+			Name:        "region",
+			Usage:       "region of v4 signature, defaults to the one in API prefix",
+			EnvVar:      "OSS_REGION",
+			Destination: &region,
+		},
 	}
 	app.BashComplete = func(c *cli.Context) {
 		for _, command := range c.App.Commands {
@@ -98,13 +121,35 @@ func main() {
 			concurrency = NUM_CPU
 		}
 
+		if c.Bool("debug") || c.String("log-file") != "" {
+			if err := trace.Enable(c.String("log-file")); err != nil {
+				return err
+			}
+		}
+
 		if strings.Count(prefix, "%s") == 1 {
 			api = fmt.Sprintf(prefix, bucket)
 		} else {
 			api = prefix
 		}
 
+		switch signatureVersion {
+		case SIGNATURE_V1:
+		case SIGNATURE_V4:
+			if region == "" {
+				region = regionOfAPI(api)
+			}
+			if region == "" {
+				return fmt.Errorf("Please specify --region for signature %s.", signatureVersion)
+			}
+		default:
+			return fmt.Errorf("Invalid signature version: %s", signatureVersion)
+		}
+
 		return nil
 	}
-	app.Run(os.Args)
+	// errors of app.Before (like invalid --signature-version) are printed with help
+	if err := app.Run(os.Args); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signature versions, selectable with --signature-version.
+const (
+	// HMAC-SHA1 of method, headers and resource
+	SIGNATURE_V1 = "v1"
+	// OSS4-HMAC-SHA256 of canonical request, scoped to region
+	SIGNATURE_V4 = "v4"
+)
+
+var signatureVersion = SIGNATURE_V1
+
+// Region of V4 credential scope, like cn-hangzhou.
+var region string
+
+const V4_ALGORITHM = "OSS4-HMAC-SHA256"
+const V4_DATE_FORMAT = "20060102T150405Z"
+const V4_UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD"
+
+// Query parameters that are part of the canonicalized resource of V1.
+var SUB_RESOURCES = map[string]bool{
+	"acl": true, "append": true, "bucketInfo": true, "callback": true,
+	"callback-var": true, "cname": true, "comp": true, "continuation-token": true,
+	"cors": true, "delete": true, "encryption": true, "endTime": true,
+	"img": true, "lifecycle": true, "live": true, "location": true,
+	"logging": true, "objectMeta": true, "partNumber": true, "policy": true,
+	"position": true, "qos": true, "referer": true, "replication": true,
+	"replicationLocation": true, "replicationProgress": true, "requestPayment": true,
+	"response-cache-control": true, "response-content-disposition": true,
+	"response-content-encoding": true, "response-content-language": true,
+	"response-content-type": true, "response-expires": true, "restore": true,
+	"security-token": true, "startTime": true, "status": true, "style": true,
+	"styleName": true, "symlink": true, "tagging": true, "uploadId": true,
+	"uploads": true, "versionId": true, "versioning": true, "versions": true,
+	"website": true, "worm": true, "wormExtend": true, "wormId": true,
+	"x-oss-process": true, "x-oss-traffic-limit": true,
+}
+
+type Signature struct {
+	Method, MD5Sum, ContentType, Date, URI string
+	// Query string of request, only sub-resources are signed in V1.
+	Query url.Values
+	// Headers of request, only x-oss- headers are signed in V1.
+	Headers http.Header
+	// Time of V4 signature, defaults to now.
+	Time time.Time
+}
+
+// Get V1 signature.
+func (signature *Signature) Get() string {
+	if signature.Method == "" {
+		signature.Method = "GET"
+	}
+	if signature.Date == "" {
+		signature.Date = time.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT") // don't use time.RFC1123
+	}
+	msg := strings.Join([]string{
+		signature.Method,
+		signature.MD5Sum,
+		signature.ContentType,
+		signature.Date,
+		signature.canonicalizedHeaders() + signature.canonicalizedResource(),
+	}, "\n")
+	mac := hmac.New(sha1.New, []byte(accessSecret))
+	mac.Write([]byte(msg))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sorted lower-case x-oss- headers, each ends with a newline.
+func (signature *Signature) canonicalizedHeaders() string {
+	var names []string
+	for name := range signature.Headers {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-oss-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var headers string
+	for _, name := range names {
+		headers += name + ":" + strings.TrimSpace(signature.Headers.Get(name)) + "\n"
+	}
+	return headers
+}
+
+// Bucket and object with sorted sub-resources, like /bucket/object?acl.
+func (signature *Signature) canonicalizedResource() string {
+	var keys []string
+	for key := range signature.Query {
+		if SUB_RESOURCES[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var subResources []string
+	for _, key := range keys {
+		if value := signature.Query.Get(key); value != "" {
+			subResources = append(subResources, key+"="+value)
+		} else {
+			subResources = append(subResources, key)
+		}
+	}
+	resource := fmt.Sprintf("/%s%s", bucket, signature.URI)
+	if len(subResources) > 0 {
+		resource += "?" + strings.Join(subResources, "&")
+	}
+	return resource
+}
+
+// Sign request with signature of signatureVersion.
+func (signature *Signature) SetRequest(req *http.Request) {
+	if signatureVersion == SIGNATURE_V4 {
+		req.Header.Set("Authorization", signature.authorizationV4())
+	} else {
+		sign := signature.Get()
+		req.Header.Set("Authorization", fmt.Sprintf("OSS %s:%s", accessKey, sign))
+		req.Header.Set("Date", signature.Date)
+	}
+	for name := range signature.Headers {
+		req.Header.Set(name, signature.Headers.Get(name))
+	}
+	if signature.MD5Sum != "" {
+		req.Header.Set("Content-MD5", signature.MD5Sum)
+	}
+	if signature.ContentType != "" {
+		req.Header.Set("Content-Type", signature.ContentType)
+	}
+}
+
+// Get value of Authorization header of V4, x-oss-date and
+// x-oss-content-sha256 are added to Headers.
+func (signature *Signature) authorizationV4() string {
+	if signature.Method == "" {
+		signature.Method = "GET"
+	}
+	if signature.Time.IsZero() {
+		signature.Time = time.Now()
+	}
+	if signature.Headers == nil {
+		signature.Headers = http.Header{}
+	}
+	signature.Headers.Set("x-oss-date", signature.Time.UTC().Format(V4_DATE_FORMAT))
+	signature.Headers.Set("x-oss-content-sha256", V4_UNSIGNED_PAYLOAD)
+	return fmt.Sprintf("%s Credential=%s/%s,Signature=%s",
+		V4_ALGORITHM, accessKey, signature.scopeV4(), signature.signV4())
+}
+
+// Credential scope of V4, like 20231203/cn-hangzhou/oss/aliyun_v4_request.
+func (signature *Signature) scopeV4() string {
+	return strings.Join([]string{signature.Time.UTC().Format("20060102"), region, "oss", "aliyun_v4_request"}, "/")
+}
+
+// Canonical request of V4. The payload is not signed.
+func (signature *Signature) canonicalRequestV4() string {
+	headers := http.Header{}
+	for name := range signature.Headers {
+		headers.Set(name, signature.Headers.Get(name))
+	}
+	if signature.MD5Sum != "" {
+		headers.Set("Content-MD5", signature.MD5Sum)
+	}
+	if signature.ContentType != "" {
+		headers.Set("Content-Type", signature.ContentType)
+	}
+	var names []string
+	for name := range headers {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-oss-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + strings.TrimSpace(headers.Get(name)) + "\n"
+	}
+	// query is sorted by encoded keys
+	encodedKeys := map[string]string{}
+	var keys []string
+	for key := range signature.Query {
+		encodedKeys[uriEncode(key, true)] = key
+		keys = append(keys, uriEncode(key, true))
+	}
+	sort.Strings(keys)
+	var query []string
+	for _, key := range keys {
+		if value := signature.Query.Get(encodedKeys[key]); value != "" {
+			query = append(query, key+"="+uriEncode(value, true))
+		} else {
+			query = append(query, key)
+		}
+	}
+	return strings.Join([]string{
+		signature.Method,
+		uriEncode(fmt.Sprintf("/%s%s", bucket, signature.URI), false),
+		strings.Join(query, "&"),
+		canonicalHeaders,
+		"", // additional headers
+		V4_UNSIGNED_PAYLOAD,
+	}, "\n")
+}
+
+// Get V4 signature of canonical request in hex.
+func (signature *Signature) signV4() string {
+	canonical := sha256.Sum256([]byte(signature.canonicalRequestV4()))
+	stringToSign := strings.Join([]string{
+		V4_ALGORITHM,
+		signature.Time.UTC().Format(V4_DATE_FORMAT),
+		signature.scopeV4(),
+		hex.EncodeToString(canonical[:]),
+	}, "\n")
+	key := hmacSHA256([]byte("aliyun_v4"+accessSecret), signature.Time.UTC().Format("20060102"))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "oss")
+	key = hmacSHA256(key, "aliyun_v4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Get query string of presigned URL of V4 that expires in seconds.
+func (signature *Signature) presignV4(expires int64) string {
+	if signature.Method == "" {
+		signature.Method = "GET"
+	}
+	if signature.Time.IsZero() {
+		signature.Time = time.Now()
+	}
+	if signature.Query == nil {
+		signature.Query = url.Values{}
+	}
+	signature.Query.Set("x-oss-signature-version", V4_ALGORITHM)
+	signature.Query.Set("x-oss-credential", accessKey+"/"+signature.scopeV4())
+	signature.Query.Set("x-oss-date", signature.Time.UTC().Format(V4_DATE_FORMAT))
+	signature.Query.Set("x-oss-expires", fmt.Sprintf("%d", expires))
+	signature.Query.Set("x-oss-signature", signature.signV4())
+	return signature.Query.Encode()
+}
+
+// Percent-encode all characters but unreserved ones of RFC 3986, and slashes
+// unless encodeSlash.
+func uriEncode(s string, encodeSlash bool) string {
+	var encoded []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !encodeSlash {
+			encoded = append(encoded, c)
+		} else {
+			encoded = append(encoded, fmt.Sprintf("%%%02X", c)...)
+		}
+	}
+	return string(encoded)
+}
+
+var regionRegexp = regexp.MustCompile(`oss-([a-z0-9-]+?)(-internal)?\.aliyuncs\.com`)
+
+// Get region from API endpoint like https://bucket.oss-cn-hangzhou.aliyuncs.com.
+func regionOfAPI(api string) string {
+	if match := regionRegexp.FindStringSubmatch(api); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func withCredentials(bucketName, secret string, fn func()) {
+	oldBucket, oldKey, oldSecret, oldRegion := bucket, accessKey, accessSecret, region
+	bucket, accessKey, accessSecret, region = bucketName, "access-key-id", secret, "cn-hangzhou"
+	defer func() {
+		bucket, accessKey, accessSecret, region = oldBucket, oldKey, oldSecret, oldRegion
+	}()
+	fn()
+}
+
+// Example of Alibaba Cloud documentation of signature V1.
+func TestSignatureV1(t *testing.T) {
+	withCredentials("oss-example", "OtxrzxIsfpFjA7SwPzILwy8Bw21TLhquhboDYROV", func() {
+		signature := &Signature{
+			Method:      "PUT",
+			MD5Sum:      "ODBGOERFMDMzQTczRUY3NUE3NzA5QzdFNUYzMDQxNEM=",
+			ContentType: "text/html",
+			Date:        "Thu, 17 Nov 2005 18:49:58 GMT",
+			URI:         "/nelson",
+			Headers: http.Header{
+				"X-Oss-Meta-Author": {"foo@bar.com"},
+				"X-Oss-Magic":       {"abracadabra"},
+			},
+		}
+		expected := "26NBxoKdsyly4EDv6inkoDft/yA="
+		if actual := signature.Get(); actual != expected {
+			t.Errorf("signature should be %s instead of %s", expected, actual)
+		}
+	})
+}
+
+func TestCanonicalizedResource(t *testing.T) {
+	withCredentials("bucket", "secret", func() {
+		signature := &Signature{
+			URI: "/file",
+			Query: url.Values{
+				"uploadId":   {"0004B9894A22E5B1888A1E29F823****"},
+				"partNumber": {"1"},
+				"max-keys":   {"100"},
+				"acl":        {""},
+			},
+		}
+		expected := "/bucket/file?acl&partNumber=1&uploadId=0004B9894A22E5B1888A1E29F823****"
+		if actual := signature.canonicalizedResource(); actual != expected {
+			t.Errorf("resource should be %s instead of %s", expected, actual)
+		}
+		if actual := (&Signature{URI: "/", Query: url.Values{"uploads": {""}}}).canonicalizedResource(); actual != "/bucket/?uploads" {
+			t.Errorf("resource should be /bucket/?uploads instead of %s", actual)
+		}
+	})
+}
+
+// Query of test vectors of Alibaba Cloud OSS SDK of signature V4, encoded
+// keys of which are sorted differently from the keys.
+func sdkQueryV4() url.Values {
+	return url.Values{
+		"param1":  {"value1"},
+		"+param1": {"value3"},
+		"|param1": {"value4"},
+		"+param2": {""},
+		"|param2": {""},
+		"param2":  {""},
+	}
+}
+
+// Test vector of Alibaba Cloud OSS SDK of signature V4 in header, headers
+// other than Content-Type, Content-MD5 and x-oss- are not signed.
+func TestSignatureV4(t *testing.T) {
+	withCredentials("bucket", "sk", func() {
+		accessKey = "ak"
+		signature := &Signature{
+			Method:      "PUT",
+			URI:         "/1234+-/123/1.txt",
+			Query:       sdkQueryV4(),
+			ContentType: "text/plain",
+			Headers: http.Header{
+				"X-Oss-Head1": {"value"},
+				"Abc":         {"value"},
+				"Zabc":        {"value"},
+			},
+			Time: time.Unix(1702743657, 0),
+		}
+		expected := "OSS4-HMAC-SHA256 Credential=ak/20231216/cn-hangzhou/oss/aliyun_v4_request," +
+			"Signature=e21d18daa82167720f9b1047ae7e7f1ce7cb77a31e8203a7d5f4624fa0284afe"
+		if actual := signature.authorizationV4(); actual != expected {
+			t.Errorf("authorization should be %s instead of %s", expected, actual)
+		}
+	})
+}
+
+// Test vector of Alibaba Cloud OSS SDK of signature V4 in query.
+func TestPresignV4(t *testing.T) {
+	withCredentials("bucket", "sk", func() {
+		accessKey = "ak"
+		signature := &Signature{
+			Method:      "PUT",
+			URI:         "/1234+-/123/1.txt",
+			Query:       sdkQueryV4(),
+			ContentType: "application/octet-stream",
+			Headers:     http.Header{"X-Oss-Head1": {"value"}},
+			Time:        time.Unix(1702781677, 0),
+		}
+		query, err := url.ParseQuery(signature.presignV4(599))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, expected := range map[string]string{
+			"x-oss-signature-version": "OSS4-HMAC-SHA256",
+			"x-oss-credential":        "ak/20231217/cn-hangzhou/oss/aliyun_v4_request",
+			"x-oss-date":              "20231217T025437Z",
+			"x-oss-expires":           "599",
+			"x-oss-signature":         "a39966c61718be0d5b14e668088b3fa07601033f6518ac7b523100014269c0fe",
+		} {
+			if actual := query.Get(key); actual != expected {
+				t.Errorf("%s should be %s instead of %s", key, expected, actual)
+			}
+		}
+	})
+}
+
+func TestRegionOfAPI(t *testing.T) {
+	for api, expected := range map[string]string{
+		"https://bucket.oss-cn-hangzhou.aliyuncs.com":         "cn-hangzhou",
+		"http://bucket.oss-cn-shanghai-internal.aliyuncs.com": "cn-shanghai",
+		"http://localhost:8080":                               "",
+	} {
+		if actual := regionOfAPI(api); actual != expected {
+			t.Errorf("region of %s should be %s instead of %s", api, expected, actual)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -24,6 +27,25 @@ var OSS_UPLOAD cli.Command = cli.Command{
 			Name:  "parents, p",
 			Usage: "use full SOURCE file name under TARGET",
 		},
+		cli.IntFlag{
+			Name:  "part-size",
+			Value: int(partSize / MB),
+			Usage: "size in MB of each part of multipart uploads, no more than 5120",
+		},
+		cli.IntFlag{
+			Name:  "multipart-threshold",
+			Value: int(multipartThreshold / MB),
+			Usage: "upload files not smaller than this size in MB in parts",
+		},
+		cli.BoolFlag{
+			Name:  "no-resume",
+			Usage: "do not resume or save multipart uploads to resume later",
+		},
+		cli.IntFlag{
+			Name:  "part-concurrency",
+			Value: partConcurrency,
+			Usage: "number of parts to upload together, uploads use about part size * part concurrency of memory",
+		},
 	},
 	Action: func(c *cli.Context) {
 		stat := (&Stat{}).Begin()
@@ -33,10 +55,19 @@ var OSS_UPLOAD cli.Command = cli.Command{
 
 		fromStdin := len(c.Args()) == 1
 
+		// CTRL-C aborts reading from stdin
+		ctx := context.Background()
+		if !fromStdin {
+			ctx = interruptContext()
+		}
+
 		defer func() {
 			if summary := stat.String(); summary != nil {
 				debug(*summary)
 			}
+			if ctx.Err() != nil {
+				os.Exit(EXIT_INTERRUPTED)
+			}
 			if totalErrors > 0 {
 				debugf("%d error(s) occurred during uploading.\n", totalErrors)
 				os.Exit(1)
@@ -45,6 +76,14 @@ var OSS_UPLOAD cli.Command = cli.Command{
 			}
 		}()
 
+		partSize = int64(c.Int("part-size")) * MB
+		if partSize > MAX_PART_SIZE {
+			die(fmt.Sprintf("Error: --part-size must be no more than %d MB.", MAX_PART_SIZE/MB))
+		}
+		multipartThreshold = int64(c.Int("multipart-threshold")) * MB
+		noResume = c.Bool("no-resume")
+		setPartConcurrency(c.Int("part-concurrency"))
+
 		if fromStdin {
 			if dryrun {
 				die()
@@ -53,7 +92,7 @@ var OSS_UPLOAD cli.Command = cli.Command{
 				die("Error: REMOTE must be a file path.")
 			}
 			readFileFromStdInPrompt()
-			ret, err := localReaderToRemoteFile(os.Stdin, remote)
+			ret, err := localReaderToRemoteFile(ctx, os.Stdin, remote)
 			if err != nil {
 				die(remote+":", err)
 			}
@@ -73,7 +112,10 @@ var OSS_UPLOAD cli.Command = cli.Command{
 						debug(err)
 						totalErrors++
 					} else if fi.Mode().IsRegular() {
-						*jobs <- []string{local, localPathToRemotePath(local, remote, localsMoreThanOne, parentsPath)}
+						select {
+						case *jobs <- []string{local, localPathToRemotePath(local, remote, localsMoreThanOne, parentsPath)}:
+						case <-ctx.Done():
+						}
 					} else {
 						err := filepath.Walk(local, func(path string, info os.FileInfo, err error) error {
 							if err != nil {
@@ -82,14 +124,21 @@ var OSS_UPLOAD cli.Command = cli.Command{
 							if !info.Mode().IsRegular() {
 								return nil
 							}
-							*jobs <- []string{path, localDirectoryToRemotePath(local, path, remote, parentsPath)}
-							return nil
+							select {
+							case *jobs <- []string{path, localDirectoryToRemotePath(local, path, remote, parentsPath)}:
+								return nil
+							case <-ctx.Done():
+								return ctx.Err()
+							}
 						})
-						if err != nil {
+						if err != nil && ctx.Err() == nil {
 							debug(err)
 							totalErrors++
 						}
 					}
+					if ctx.Err() != nil {
+						return
+					}
 				}
 			},
 			DoJob: func(job *interface{}) {
@@ -98,9 +147,11 @@ var OSS_UPLOAD cli.Command = cli.Command{
 					fmt.Println(paths[0], " -> ", paths[1])
 					return
 				}
-				size, err := localFileToRemoteFile(paths[0], paths[1])
+				size, err := localFileToRemoteFile(ctx, paths[0], paths[1])
 				if err == nil {
 					stat.Add(size)
+				} else if ctx.Err() != nil {
+					debug(paths[1]+":", "interrupted")
 				} else {
 					debug(paths[0], err)
 					totalErrors++
@@ -143,37 +194,103 @@ func parseArgsForOSSUpload(args []string) (locals []string, remote string) {
 	return
 }
 
-func getHeader(remote, key string) (value string, err error) {
-	var resp *http.Response
-	resp, err = sendRequest("HEAD", remote, nil, nil)
+// Get hex MD5 of remote file, empty if the file does not exist or its MD5 is
+// unknown.
+func getRemoteMD5(ctx context.Context, remote string) string {
+	resp, err := sendRequest(ctx, "HEAD", remote, nil, nil)
 	if err != nil {
-		return
+		return ""
 	}
-	value = resp.Header.Get(key)
-	return
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return ""
+	}
+	return md5OfHeader(resp.Header)
+}
+
+// Get hex MD5 of file of header, from MD5_META_HEADER of multipart uploads
+// or ETag of others.
+func md5OfHeader(header http.Header) string {
+	if md5sum := header.Get(MD5_META_HEADER); md5sum != "" {
+		return strings.ToLower(md5sum)
+	}
+	etag := strings.ToLower(strings.Trim(header.Get("ETag"), "\""))
+	if strings.Contains(etag, "-") {
+		return ""
+	}
+	return etag
 }
 
-func localFileToRemoteFile(local, remote string) (size int64, err error) {
+// Get hex MD5 of local file without reading it into memory.
+func fileMD5(local string) (string, error) {
+	file, err := os.Open(local)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func localFileToRemoteFile(ctx context.Context, local, remote string) (size int64, err error) {
+	var info os.FileInfo
+	info, err = os.Stat(local)
+	if err != nil {
+		return
+	}
+	if info.Size() > 0 && info.Size() >= multipartThreshold {
+		var localMD5, remoteMD5 string
+		gotogether.Parallel{
+			func() {
+				localMD5, err = fileMD5(local)
+			},
+			func() {
+				remoteMD5 = getRemoteMD5(ctx, remote)
+			},
+		}.Run()
+		if err != nil {
+			return
+		}
+		if localMD5 == remoteMD5 {
+			fmt.Println(remote+":", "no changes, ignored")
+			return
+		}
+		size, err = localFileToRemoteFileMultipart(ctx, local, remote, info, localMD5)
+		return
+	}
 	var localFile []byte
 	localFile, err = ioutil.ReadFile(local)
 	if err != nil {
 		return
 	}
-	size, err = localBytesToRemoteFile(localFile, remote)
+	size, err = localBytesToRemoteFile(ctx, localFile, remote)
 	return
 }
 
-func localReaderToRemoteFile(r io.Reader, remote string) (size int64, err error) {
-	var localFile []byte
-	localFile, err = ioutil.ReadAll(r)
+// Upload contents of r, in parts if there are at least multipartThreshold
+// bytes, so that r is not read into memory at once.
+func localReaderToRemoteFile(ctx context.Context, r io.Reader, remote string) (size int64, err error) {
+	limit := multipartThreshold
+	if limit < 1 {
+		limit = 1
+	}
+	var head []byte
+	head, err = ioutil.ReadAll(io.LimitReader(r, limit))
 	if err != nil {
 		return
 	}
-	size, err = localBytesToRemoteFile(localFile, remote)
+	if int64(len(head)) >= limit {
+		size, err = readerToRemoteFileMultipart(ctx, io.MultiReader(bytes.NewReader(head), r), remote)
+		return
+	}
+	size, err = localBytesToRemoteFile(ctx, head, remote)
 	return
 }
 
-func localBytesToRemoteFile(localFile []byte, remote string) (size int64, err error) {
+func localBytesToRemoteFile(ctx context.Context, localFile []byte, remote string) (size int64, err error) {
 	var localFileMD5 []byte
 	var localMD5, remoteMD5 string
 	gotogether.Parallel{
@@ -182,10 +299,7 @@ func localBytesToRemoteFile(localFile []byte, remote string) (size int64, err er
 			localMD5 = fmt.Sprintf("%x", localFileMD5)
 		},
 		func() {
-			etag, err := getHeader(remote, "Etag")
-			if err == nil {
-				remoteMD5 = strings.ToLower(strings.Replace(etag, "\"", "", -1))
-			}
+			remoteMD5 = getRemoteMD5(ctx, remote)
 		},
 	}.Run()
 	if localMD5 != "" && localMD5 == remoteMD5 {
@@ -194,7 +308,7 @@ func localBytesToRemoteFile(localFile []byte, remote string) (size int64, err er
 	}
 	fmt.Println(remote+":", "uploading")
 	var resp *http.Response
-	resp, err = sendRequest("PUT", remote, localFile, localFileMD5)
+	resp, err = sendRequest(ctx, "PUT", remote, localFile, localFileMD5)
 	if err != nil {
 		return
 	}
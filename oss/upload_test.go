@@ -1,6 +1,7 @@
 package main
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -157,3 +158,15 @@ func TestLocalDirectoryToRemotePath(t *testing.T) {
 		"test/fixtures/f":     "/e/test/fixtures/f",
 	})
 }
+
+func TestMD5OfHeader(t *testing.T) {
+	for expected, header := range map[string]http.Header{
+		"d41d8cd98f00b204e9800998ecf8427e": {"Etag": {`"D41D8CD98F00B204E9800998ECF8427E"`}},
+		"0cc175b9c0f1b6a831c399e269772661": {"Etag": {`"5B2D4F0E3F0F1A4C6E2B1D7A4C3E2F10-3"`}, "X-Oss-Meta-Md5": {"0cc175b9c0f1b6a831c399e269772661"}},
+		"":                                 {"Etag": {`"5B2D4F0E3F0F1A4C6E2B1D7A4C3E2F10-3"`}},
+	} {
+		if actual := md5OfHeader(header); actual != expected {
+			t.Errorf("MD5 of %v should be %q instead of %q", header, expected, actual)
+		}
+	}
+}
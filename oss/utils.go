@@ -2,22 +2,29 @@ package main
 
 import (
 	"bytes"
-	"crypto/hmac"
+	"context"
 	"crypto/md5"
-	"crypto/sha1"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
+
+	"github.com/caiguanhao/aliyun/journal"
+	"github.com/caiguanhao/aliyun/redact"
+	"github.com/caiguanhao/aliyun/trace"
 )
 
 func debug(a ...interface{}) {
@@ -28,6 +35,26 @@ func debugf(format string, a ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, a...)
 }
 
+// Exit code when interrupted, 128 + SIGINT like shells.
+const EXIT_INTERRUPTED = 130
+
+// Returns a context that is cancelled on the first interrupt, so that no
+// more jobs are started and running requests are aborted. The second
+// interrupt exits immediately.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		debug("Interrupted, stopping. Press CTRL-C again to quit now.")
+		cancel()
+		<-signals
+		os.Exit(EXIT_INTERRUPTED)
+	}()
+	return ctx
+}
+
 func die(msg ...interface{}) {
 	if len(msg) > 0 {
 		fmt.Fprintln(os.Stderr, msg...)
@@ -35,41 +62,6 @@ func die(msg ...interface{}) {
 	os.Exit(1)
 }
 
-type Signature struct {
-	Method, MD5Sum, ContentType, Date, URI string
-}
-
-func (signature *Signature) Get() string {
-	if signature.Method == "" {
-		signature.Method = "GET"
-	}
-	if signature.Date == "" {
-		signature.Date = time.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT") // don't use time.RFC1123
-	}
-	msg := strings.Join([]string{
-		signature.Method,
-		signature.MD5Sum,
-		signature.ContentType,
-		signature.Date,
-		fmt.Sprintf("/%s%s", bucket, signature.URI),
-	}, "\n")
-	mac := hmac.New(sha1.New, []byte(accessSecret))
-	mac.Write([]byte(msg))
-	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
-}
-
-func (signature *Signature) SetRequest(req *http.Request) {
-	sign := signature.Get()
-	req.Header.Set("Authorization", fmt.Sprintf("OSS %s:%s", accessKey, sign))
-	if signature.MD5Sum != "" {
-		req.Header.Set("Content-MD5", signature.MD5Sum)
-	}
-	if signature.ContentType != "" {
-		req.Header.Set("Content-Type", signature.ContentType)
-	}
-	req.Header.Set("Date", signature.Date)
-}
-
 type Stat struct {
 	timeStart time.Time
 	total     int64
@@ -81,7 +73,7 @@ func (stat *Stat) Begin() *Stat {
 }
 
 func (stat *Stat) Add(n int64) {
-	stat.total += n
+	atomic.AddInt64(&stat.total, n)
 }
 
 func (stat *Stat) String() *string {
@@ -102,15 +94,26 @@ func md5hash(file []byte) []byte {
 	return md5sum.Sum(nil)
 }
 
-func sendRequest(method, remote string, localFile []byte, localFileMD5 []byte) (resp *http.Response, err error) {
+func sendRequest(ctx context.Context, method, remote string, localFile []byte, localFileMD5 []byte) (resp *http.Response, err error) {
+	return sendRequestWithHeaders(ctx, method, remote, localFile, localFileMD5, nil)
+}
+
+// Send request with extra headers, Content-Type of headers overrides the one
+// detected from localFile.
+func sendRequestWithHeaders(ctx context.Context, method, remote string, localFile []byte, localFileMD5 []byte, headers http.Header) (resp *http.Response, err error) {
 	var req *http.Request
 	req, err = http.NewRequest(method, api+remote, bytes.NewReader(localFile))
 	if err != nil {
 		return
 	}
+	req = req.WithContext(ctx)
 	var md5sum, contentType, remoteNoQS string
+	var query url.Values
 	if i := strings.Index(remote, "?"); i > -1 {
 		remoteNoQS = remote[:i]
+		if query, err = url.ParseQuery(remote[i+1:]); err != nil {
+			return
+		}
 	} else {
 		remoteNoQS = remote
 	}
@@ -122,14 +125,55 @@ func sendRequest(method, remote string, localFile []byte, localFileMD5 []byte) (
 		}
 		contentType = http.DetectContentType(localFile)
 	}
-	(&Signature{Method: method, MD5Sum: md5sum, ContentType: contentType, URI: remoteNoQS}).SetRequest(req)
-	client := &http.Client{}
+	if headers.Get("Content-Type") != "" {
+		contentType = headers.Get("Content-Type")
+		headers = cloneHeader(headers)
+		headers.Del("Content-Type")
+	}
+	(&Signature{Method: method, MD5Sum: md5sum, ContentType: contentType, URI: remoteNoQS, Query: query, Headers: headers}).SetRequest(req)
+	client := &http.Client{Transport: &trace.Transport{}}
 	resp, err = client.Do(req)
+	err = redact.Error(err)
+	// multipart uploads are recorded once when they complete or fail
+	_, isMultipart := query["uploadId"]
+	if _, ok := query["uploads"]; ok {
+		isMultipart = true
+	}
+	if method != "GET" && method != "HEAD" && !isMultipart {
+		recordRequest(method, remoteNoQS, int64(len(localFile)), resp, err)
+	}
 	return
 }
 
-func sendGetRequest(remote string) (resp *http.Response, err error) {
-	resp, err = sendRequest("GET", remote, nil, nil)
+func cloneHeader(header http.Header) http.Header {
+	clone := http.Header{}
+	for key, values := range header {
+		clone[key] = values
+	}
+	return clone
+}
+
+// Add request that changes remote files to journal.
+func recordRequest(method, remote string, size int64, resp *http.Response, err error) {
+	entry := journal.Entry{
+		Tool:    "oss",
+		Action:  method,
+		Params:  map[string]string{"Size": strconv.FormatInt(size, 10)},
+		Targets: []string{fmt.Sprintf("oss://%s%s", bucket, remote)},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.RequestId = resp.Header.Get("X-Oss-Request-Id")
+		if resp.StatusCode >= 300 {
+			entry.Error = resp.Status
+		}
+	}
+	journal.RecordOrWarn(entry)
+}
+
+func sendGetRequest(ctx context.Context, remote string) (resp *http.Response, err error) {
+	resp, err = sendRequest(ctx, "GET", remote, nil, nil)
 	return
 }
 
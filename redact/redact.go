@@ -0,0 +1,92 @@
+// Masking of secrets like passwords, access keys and signatures before
+// parameters, command lines, URLs or headers are printed or saved.
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const REDACTED = "******"
+
+var secretRegexp = regexp.MustCompile(`(?i)password|secret|^(--?|x-oss-)?signature(=|$)|accesskey|credential|token|^authorization$`)
+
+// Whether value of the parameter, header or flag should never be shown.
+func IsSecret(name string) bool {
+	return secretRegexp.MatchString(name)
+}
+
+// Copy of params with values of secrets redacted.
+func Params(params map[string]string) map[string]string {
+	redacted := map[string]string{}
+	for key, value := range params {
+		if IsSecret(key) {
+			value = REDACTED
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// Copy of command line arguments with values of secret flags redacted, like
+// --password xxx or --secret=xxx.
+func Args(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		arg := redacted[i]
+		if !strings.HasPrefix(arg, "-") || !IsSecret(arg) {
+			continue
+		}
+		if index := strings.Index(arg, "="); index > -1 {
+			redacted[i] = arg[:index+1] + REDACTED
+		} else if i+1 < len(redacted) {
+			i++
+			redacted[i] = REDACTED
+		}
+	}
+	return redacted
+}
+
+// URL with values of secret query parameters redacted. Order of the
+// parameters is kept.
+func URL(rawurl string) string {
+	index := strings.Index(rawurl, "?")
+	if index < 0 {
+		return rawurl
+	}
+	pairs := strings.Split(rawurl[index+1:], "&")
+	for i, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		key, err := url.QueryUnescape(kv[0])
+		if err != nil {
+			key = kv[0]
+		}
+		if len(kv) == 2 && IsSecret(key) {
+			pairs[i] = kv[0] + "=" + REDACTED
+		}
+	}
+	return rawurl[:index+1] + strings.Join(pairs, "&")
+}
+
+// Error with secrets in URL redacted, if it is an error of request to URL.
+func Error(err error) error {
+	if e, ok := err.(*url.Error); ok {
+		return &url.Error{Op: e.Op, URL: URL(e.URL), Err: e.Err}
+	}
+	return err
+}
+
+// Copy of header with values of secrets redacted.
+func Header(header http.Header) http.Header {
+	redacted := http.Header{}
+	for key, values := range header {
+		if IsSecret(key) {
+			values = []string{REDACTED}
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
@@ -0,0 +1,59 @@
+package redact
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	args := []string{"ecs", "create", "--password", "p4ss", "--secret=s3cret", "--name", "web"}
+	expected := []string{"ecs", "create", "--password", REDACTED, "--secret=" + REDACTED, "--name", "web"}
+	if actual := Args(args); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Args should be %v instead of %v", expected, actual)
+	}
+	if args[3] != "p4ss" {
+		t.Error("Args should not change args")
+	}
+}
+
+func TestParams(t *testing.T) {
+	params := map[string]string{"Password": "p4ss", "AccessKeyId": "key", "Signature": "sign", "SignatureMethod": "HMAC-SHA1", "InstanceId": "i-1"}
+	expected := map[string]string{"Password": REDACTED, "AccessKeyId": REDACTED, "Signature": REDACTED, "SignatureMethod": "HMAC-SHA1", "InstanceId": "i-1"}
+	if actual := Params(params); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Params should be %v instead of %v", expected, actual)
+	}
+}
+
+func TestURL(t *testing.T) {
+	for input, expected := range map[string]string{
+		"http://ecs.aliyuncs.com/?AccessKeyId=key&Action=DescribeRegions&SignatureMethod=HMAC-SHA1&Signature=abc%3D":                       "http://ecs.aliyuncs.com/?AccessKeyId=******&Action=DescribeRegions&SignatureMethod=HMAC-SHA1&Signature=******",
+		"https://bucket.oss.aliyuncs.com/a.txt?OSSAccessKeyId=key&Expires=1&Signature=abc":                                                 "https://bucket.oss.aliyuncs.com/a.txt?OSSAccessKeyId=******&Expires=1&Signature=******",
+		"https://bucket.oss.aliyuncs.com/a.txt?x-oss-signature-version=OSS4-HMAC-SHA256&x-oss-signature=abc":                               "https://bucket.oss.aliyuncs.com/a.txt?x-oss-signature-version=OSS4-HMAC-SHA256&x-oss-signature=******",
+		"https://bucket.oss.aliyuncs.com/a.txt?x-oss-credential=key%2F20231203%2Fcn-hangzhou%2Foss%2Faliyun_v4_request&x-oss-expires=3600": "https://bucket.oss.aliyuncs.com/a.txt?x-oss-credential=******&x-oss-expires=3600",
+		"https://bucket.oss.aliyuncs.com/a.txt": "https://bucket.oss.aliyuncs.com/a.txt",
+	} {
+		if actual := URL(input); actual != expected {
+			t.Errorf("URL(%q) should be %q instead of %q", input, expected, actual)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	err := Error(&url.Error{Op: "Get", URL: "http://ecs.aliyuncs.com/?AccessKeyId=key&Signature=sign", Err: errors.New("timeout")})
+	expected := "http://ecs.aliyuncs.com/?AccessKeyId=******&Signature=******"
+	if !strings.Contains(err.Error(), expected) || strings.Contains(err.Error(), "key") {
+		t.Errorf("Error should contain %q instead of %q", expected, err.Error())
+	}
+}
+
+func TestHeader(t *testing.T) {
+	header := http.Header{"Authorization": {"OSS key:sign"}, "X-Oss-Security-Token": {"token"}, "Date": {"today"}}
+	expected := http.Header{"Authorization": {REDACTED}, "X-Oss-Security-Token": {REDACTED}, "Date": {"today"}}
+	if actual := Header(header); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Header should be %v instead of %v", expected, actual)
+	}
+}
@@ -0,0 +1,114 @@
+// Debug logging of HTTP requests with secrets masked.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/aliyun/redact"
+)
+
+var (
+	mutex  sync.Mutex
+	output io.Writer
+)
+
+// Start logging requests to file, or stderr if file is empty.
+func Enable(file string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if file == "" {
+		output = os.Stderr
+		return nil
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	output = f
+	return nil
+}
+
+func Enabled() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return output != nil
+}
+
+// Write a line to the log if logging is enabled.
+func Printf(format string, a ...interface{}) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if output == nil {
+		return
+	}
+	fmt.Fprintf(output, time.Now().Format("2006-01-02T15:04:05.000Z07:00")+" "+format+"\n", a...)
+}
+
+type attemptKey struct{}
+
+// Context of the nth attempt of a request, which is logged with it.
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+func attempt(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// Transport logs method, URL, headers, status, latency and size of
+// requests and responses when logging is enabled.
+type Transport struct {
+	// http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !Enabled() {
+		return base.RoundTrip(req)
+	}
+	n := attempt(req.Context())
+	Printf("> %s %s (attempt %d)", req.Method, redact.URL(req.URL.String()), n)
+	printHeader(">", req.Header)
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	latency := time.Since(start)
+	if err != nil {
+		Printf("< %s %s failed after %s: %s", req.Method, redact.URL(req.URL.String()), latency, redact.Error(err))
+		return resp, err
+	}
+	size := "unknown size"
+	if resp.ContentLength > -1 {
+		size = fmt.Sprintf("%d bytes", resp.ContentLength)
+	}
+	Printf("< %s %s in %s, %s", resp.Proto, resp.Status, latency, size)
+	printHeader("<", resp.Header)
+	return resp, err
+}
+
+func printHeader(prefix string, header http.Header) {
+	header = redact.Header(header)
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			Printf("%s %s: %s", prefix, key, value)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	var log bytes.Buffer
+	output = &log
+	defer func() { output = nil }()
+
+	req, _ := http.NewRequest("GET", server.URL+"/?AccessKeyId=key&Action=Test&Signature=sign", nil)
+	req.Header.Set("Authorization", "OSS key:sign")
+	req = req.WithContext(WithAttempt(req.Context(), 2))
+	resp, err := (&http.Client{Transport: &Transport{}}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, expected := range []string{
+		"> GET " + server.URL + "/?AccessKeyId=******&Action=Test&Signature=****** (attempt 2)",
+		"> Authorization: ******",
+		"< HTTP/1.1 200 OK in ",
+		", 5 bytes",
+	} {
+		if !strings.Contains(log.String(), expected) {
+			t.Errorf("log should contain %q:\n%s", expected, log.String())
+		}
+	}
+	for _, secret := range []string{"key", "sign"} {
+		if strings.Contains(log.String(), "="+secret) || strings.Contains(log.String(), "OSS key") {
+			t.Errorf("log should not contain %q:\n%s", secret, log.String())
+		}
+	}
+}